@@ -1,15 +1,19 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/walkure/comic2atom/internal/cli"
+	"github.com/walkure/comic2atom/internal/metrics"
 	"github.com/walkure/comic2atom/siteloader"
 )
 
@@ -17,6 +21,22 @@ var (
 	targets        = flag.String("targets", "", "check target uri(s)")
 	list           = flag.String("list", "", "targets url(s) list")
 	atomPathPrefix = flag.String("atom", "", "atom file save path prefix")
+	format         = flag.String("format", "atom", "output format(s) of targets without format (atom,rss,json)")
+	disabledSites  = flag.String("disable", "", "disabled site name(s)")
+	sitesPath      = flag.String("sites", "", "site definition file (YAML or JSON)")
+	legacyIDs      = flag.Bool("legacy-ids", false, "use feed/entry IDs of former versions instead of tag: URIs")
+	probeMedia     = flag.Bool("probe-media", false, "send HEAD requests for the media type of thumbnails unknown from the extension")
+	statePath      = flag.String("state", "", "state file to record first seen time of entries")
+	archivePath    = flag.String("archive", "", "archive file to keep entries removed by the site")
+	archiveMaxAge  = flag.Duration("archive-max-age", 0, "how long removed entries are kept (0: forever)")
+	archiveMax     = flag.Int("archive-max-entries", 0, "max entries of each feed with archive (0: unlimited)")
+	fullText       = flag.Bool("full-text", false, "embed the body of episodes in entries (novel sites)")
+	bodyCachePath  = flag.String("body-cache", "", "cache file of the body of episodes with -full-text")
+	maxBodies      = flag.Int("max-bodies", 50, "max bodies fetched in a run with -full-text (0: unlimited)")
+	parallel       = flag.Int("parallel", 4, "max targets fetched in parallel")
+	clientFlags    = cli.RegisterClientFlags(flag.CommandLine, cli.ClientDefaults{Retries: 3, RetryMaxWait: 30 * time.Second})
+	logFlags       = cli.RegisterLogFlags(flag.CommandLine)
+	metricsPath    = flag.String("metrics-textfile", "", "metrics file for the textfile collector of node-exporter (e.g. /var/lib/node_exporter/comic2atom.prom)")
 )
 
 func init() {
@@ -24,96 +44,221 @@ func init() {
 }
 
 func main() {
+	logger, err := logFlags.NewLogger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot create logger:%v\n", err)
+		os.Exit(2)
+	}
+
 	if (*targets == "" && *list == "") || *atomPathPrefix == "" {
-		log.Fatal("requires target,list and atom arguments.")
+		cli.Fatal(logger, "requires target,list and atom arguments.")
 	}
 
-	var targetUris []string
+	if *sitesPath != "" {
+		loaders, err := siteloader.LoadSiteDefinitions(*sitesPath)
+		if err != nil {
+			cli.Fatal(logger, "cannot load site definitions", "error", err)
+		}
+		for _, l := range loaders {
+			siteloader.DefaultRegistry.Replace(l)
+		}
+	}
+
+	if err := cli.DisableSites(siteloader.DefaultRegistry, *disabledSites); err != nil {
+		cli.Fatal(logger, "cannot disable site", "error", err)
+	}
+
+	client, err := clientFlags.NewClient()
+	if err != nil {
+		cli.Fatal(logger, "cannot create HTTP client", "error", err)
+	}
+	ctx := siteloader.SetClient(context.Background(), client)
+	ctx = siteloader.SetLegacyIDs(ctx, *legacyIDs)
+	ctx = siteloader.SetProbeMedia(ctx, *probeMedia)
+	ctx = siteloader.SetLogger(ctx, logger)
+
+	var collector *metrics.Collector
+	if *metricsPath != "" {
+		collector = metrics.New()
+		ctx = siteloader.SetHooks(ctx, collector.Hooks())
+	}
+
+	var store *siteloader.StateStore
+	if *statePath != "" {
+		store, err = siteloader.OpenStateStore(*statePath)
+		if err != nil {
+			cli.Fatal(logger, "cannot open state", "error", err)
+		}
+		ctx = siteloader.SetStateStore(ctx, store)
+	}
+
+	var archive *siteloader.Archive
+	if *archivePath != "" {
+		archive, err = siteloader.OpenArchive(*archivePath, siteloader.ArchiveRetention{
+			MaxAge:     *archiveMaxAge,
+			MaxEntries: *archiveMax,
+		})
+		if err != nil {
+			cli.Fatal(logger, "cannot open archive", "error", err)
+		}
+		ctx = siteloader.SetArchive(ctx, archive)
+	}
+
+	var bodyCache *siteloader.BodyCache
+	if *fullText {
+		if *bodyCachePath != "" {
+			bodyCache, err = siteloader.OpenBodyCache(*bodyCachePath)
+			if err != nil {
+				cli.Fatal(logger, "cannot open body cache", "error", err)
+			}
+		}
+		ctx = siteloader.SetFullText(ctx, bodyCache, *maxBodies)
+	}
+
+	defaultFormats, err := parseFormats(*format)
+	if err != nil {
+		cli.Fatal(logger, "invalid format", "error", err)
+	}
+
+	var targetSpecs []string
 
 	if *targets != "" {
 		if strings.Contains(*targets, ",") {
-			targetUris = append(targetUris, strings.Split(*targets, ",")...)
+			targetSpecs = append(targetSpecs, strings.Split(*targets, ",")...)
 
 		} else {
-			targetUris = append(targetUris, *targets)
+			targetSpecs = append(targetSpecs, *targets)
 		}
 	}
 
 	if *list != "" {
-		loaded, err := loadList(*list)
+		loaded, err := cli.LoadList(*list)
 		if err != nil {
-			fmt.Printf("cannot load file(%s):%v", *list, err)
+			logger.Error("cannot load list", "path", *list, "error", err)
 		}
-		targetUris = append(targetUris, loaded...)
+		targetSpecs = append(targetSpecs, loaded...)
+	}
+
+	// a target may be followed by its format(s). e.g. "https://example.com/1 atom,rss"
+	var targetUris []string
+	targetFormats := map[string][]siteloader.Format{}
+	for _, spec := range targetSpecs {
+		fields := strings.Fields(spec)
+		if len(fields) == 0 {
+			continue
+		}
+		formats := defaultFormats
+		if len(fields) > 1 {
+			formats, err = parseFormats(fields[1])
+			if err != nil {
+				logger.Error("invalid format", "target", fields[0], "error", err)
+				continue
+			}
+		}
+		targetUris = append(targetUris, fields[0])
+		targetFormats[fields[0]] = formats
 	}
 
 	if len(targetUris) == 0 {
-		fmt.Printf("no target found from args(%s) nor list(%s)", *targets, *list)
+		logger.Warn("no target found", "targets", *targets, "list", *list)
 	}
 
 	errored := false
-	for _, target := range targetUris {
-		err := processTarget(target, *atomPathPrefix)
+	// selectors broken for each site
+	brokenSites := map[string][]string{}
+	for result := range siteloader.GetFeeds(ctx, targetUris, *parallel) {
+		paths, err := saveFeed(result, *atomPathPrefix, targetFormats[result.Target])
 		if err != nil {
-			fmt.Printf("Error:%v\n", err)
+			logger.Error("feed failed",
+				"target", result.Target,
+				"error_class", siteloader.ErrorClass(err),
+				"error", err,
+			)
 			errored = true
+
+			var layout *siteloader.LayoutError
+			if errors.As(err, &layout) {
+				brokenSites[layout.Site] = append(brokenSites[layout.Site], layout.Selector)
+			}
+			continue
 		}
+		logger.Info("feed saved", "target", result.Target, "paths", paths)
 	}
 
-	if errored {
-		os.Exit(255)
+	for _, site := range slices.Sorted(maps.Keys(brokenSites)) {
+		logger.Warn("scraper maintenance required", "site", site, "selectors", brokenSites[site])
 	}
-}
 
-func loadList(listPath string) ([]string, error) {
-	fp, err := os.Open(listPath)
-	if err != nil {
-		return nil, fmt.Errorf("cannot open file: %w", err)
+	if store != nil {
+		if err := store.Save(); err != nil {
+			logger.Error("cannot save state", "error", err)
+			errored = true
+		}
 	}
-	defer fp.Close()
 
-	scanner := bufio.NewScanner(fp)
-	var list []string
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line != "" && line[0] != '#' {
-			list = append(list, line)
+	if archive != nil {
+		if err := archive.Save(); err != nil {
+			logger.Error("cannot save archive", "error", err)
+			errored = true
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("scanner error: %w", err)
+	if bodyCache != nil {
+		if err := bodyCache.Save(); err != nil {
+			logger.Error("cannot save body cache", "error", err)
+			errored = true
+		}
 	}
 
-	return list, nil
+	if collector != nil {
+		if err := collector.WriteFile(*metricsPath); err != nil {
+			logger.Error("cannot write metrics", "error", err)
+			errored = true
+		}
+	}
 
+	if errored {
+		os.Exit(255)
+	}
 }
 
-func processTarget(targetUri, pathPrefix string) error {
-
-	fmt.Printf("Fetch %s ", targetUri)
+func parseFormats(names string) ([]siteloader.Format, error) {
+	var formats []siteloader.Format
+	for _, name := range strings.Split(names, ",") {
+		f, err := siteloader.ParseFormat(name)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, f)
+	}
+	return formats, nil
+}
 
-	fname, feed, _, err := siteloader.GetFeed(context.TODO(), targetUri)
+func saveFeed(result siteloader.FeedResult, pathPrefix string, formats []siteloader.Format) ([]string, error) {
 
-	if err != nil {
-		return err
+	if result.Err != nil {
+		return nil, result.Err
 	}
 
-	atomData, err := feed.ToAtom()
-	if err != nil {
-		return err
-	}
+	var paths []string
+	for _, f := range formats {
+		feedData, err := siteloader.Render(result.Series, f, *legacyIDs)
+		if err != nil {
+			return paths, err
+		}
 
-	atomPath := filepath.Join(pathPrefix, "/", fname+".atom")
+		feedPath := filepath.Join(pathPrefix, "/", result.Key+f.Extension())
 
-	file, err := os.OpenFile(atomPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
-	}
+		file, err := os.OpenFile(feedPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return paths, err
+		}
 
-	file.WriteString(atomData)
-	file.Close()
+		file.WriteString(feedData)
+		file.Close()
+
+		paths = append(paths, feedPath)
+	}
 
-	fmt.Printf("-> %s\n", atomPath)
-	return nil
+	return paths, nil
 }
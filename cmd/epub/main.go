@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/walkure/comic2atom/internal/cli"
+	"github.com/walkure/comic2atom/internal/epub"
+	"github.com/walkure/comic2atom/siteloader"
+)
+
+var (
+	targets       = flag.String("targets", "", "novel url(s) (narou, kakuyomu)")
+	list          = flag.String("list", "", "novel url(s) list")
+	outDir        = flag.String("out", "", "EPUB file save directory")
+	force         = flag.Bool("force", false, "rebuild EPUB even if no episode is updated")
+	bodyCachePath = flag.String("body-cache", "", "cache file of the body of episodes not to fetch them on each rebuild")
+	maxBodies     = flag.Int("max-bodies", 0, "max bodies fetched in a run (0: unlimited)")
+	disabledSites = flag.String("disable", "", "disabled site name(s)")
+	clientFlags   = cli.RegisterClientFlags(flag.CommandLine, cli.ClientDefaults{Retries: 3, RetryMaxWait: 30 * time.Second})
+	logFlags      = cli.RegisterLogFlags(flag.CommandLine)
+)
+
+func init() {
+	flag.Parse()
+}
+
+func main() {
+	logger, err := logFlags.NewLogger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot create logger:%v\n", err)
+		os.Exit(2)
+	}
+
+	if (*targets == "" && *list == "") || *outDir == "" {
+		cli.Fatal(logger, "requires target,list and out arguments.")
+	}
+
+	if err := cli.DisableSites(siteloader.DefaultRegistry, *disabledSites); err != nil {
+		cli.Fatal(logger, "cannot disable site", "error", err)
+	}
+
+	client, err := clientFlags.NewClient()
+	if err != nil {
+		cli.Fatal(logger, "cannot create HTTP client", "error", err)
+	}
+	ctx := siteloader.SetClient(context.Background(), client)
+	ctx = siteloader.SetLogger(ctx, logger)
+
+	var bodyCache *siteloader.BodyCache
+	if *bodyCachePath != "" {
+		bodyCache, err = siteloader.OpenBodyCache(*bodyCachePath)
+		if err != nil {
+			cli.Fatal(logger, "cannot open body cache", "error", err)
+		}
+	}
+	// bodies are loaded after checking the series is updated. one limit for the run.
+	bodyCtx := siteloader.SetFullText(ctx, bodyCache, *maxBodies)
+
+	var targetUris []string
+	if *targets != "" {
+		targetUris = append(targetUris, strings.Split(*targets, ",")...)
+	}
+	if *list != "" {
+		loaded, err := cli.LoadList(*list)
+		if err != nil {
+			logger.Error("cannot load list", "path", *list, "error", err)
+		}
+		// lines may be followed by feed formats for converter.
+		for _, line := range loaded {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				targetUris = append(targetUris, fields[0])
+			}
+		}
+	}
+
+	if len(targetUris) == 0 {
+		logger.Warn("no target found", "targets", *targets, "list", *list)
+	}
+
+	errored := false
+	for _, target := range targetUris {
+		path, err := saveBook(ctx, bodyCtx, target, *outDir)
+		switch {
+		case errors.Is(err, siteloader.ErrNotModified):
+			logger.Info("book not modified", "target", target, "path", path)
+		case err != nil:
+			logger.Error("book failed",
+				"target", target,
+				"error_class", siteloader.ErrorClass(err),
+				"error", err,
+			)
+			errored = true
+		default:
+			logger.Info("book saved", "target", target, "path", path)
+		}
+	}
+
+	if bodyCache != nil {
+		if err := bodyCache.Save(); err != nil {
+			logger.Error("cannot save body cache", "error", err)
+			errored = true
+		}
+	}
+
+	if errored {
+		os.Exit(255)
+	}
+}
+
+// saveBook saves the EPUB of target into dir unless no episode is updated since the last build.
+// siteloader.ErrNotModified is returned if not rebuilt.
+func saveBook(ctx, bodyCtx context.Context, target, dir string) (string, error) {
+	series, metadata, err := siteloader.GetSeries(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, series.Key+".epub")
+
+	// the version of the book is the ETag of the series, computed from the episodes.
+	if !*force {
+		version, err := epub.ReadVersion(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return path, fmt.Errorf("cannot read the book:%w", err)
+		}
+		if version != "" && version == metadata.ETag {
+			return path, siteloader.ErrNotModified
+		}
+	}
+
+	// bodies only of updated series
+	if err := siteloader.LoadBodies(bodyCtx, target, series); err != nil {
+		return path, err
+	}
+
+	book := &epub.Book{
+		Identifier:  series.TagURI(),
+		Title:       series.Title,
+		Author:      series.Author,
+		Description: series.Description,
+		Source:      series.Link,
+		Modified:    time.Now(),
+		Version:     metadata.ETag,
+	}
+	for _, ep := range series.Episodes {
+		if ep.Content == "" {
+			// rebuild on the next run to fill the body.
+			book.Version = ""
+		}
+		book.Sections = append(book.Sections, epub.Section{
+			Chapter: ep.Chapter,
+			Title:   ep.Title,
+			Link:    ep.Link,
+			Body:    ep.Content,
+		})
+	}
+
+	if err := book.WriteFile(path); err != nil {
+		return path, err
+	}
+	return path, nil
+}
@@ -4,72 +4,347 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/walkure/comic2atom/internal/cli"
+	"github.com/walkure/comic2atom/internal/metrics"
 	"github.com/walkure/comic2atom/siteloader"
 )
 
-var listener = flag.String("listener", ":8080", "listen address and port")
+var (
+	listener      = flag.String("listener", ":8080", "listen address and port")
+	disabledSites = flag.String("disable", "", "disabled site name(s)")
+	sitesPath     = flag.String("sites", "", "site definition file (YAML or JSON)")
+	legacyIDs     = flag.Bool("legacy-ids", false, "use feed/entry IDs of former versions instead of tag: URIs")
+	probeMedia    = flag.Bool("probe-media", false, "send HEAD requests for the media type of thumbnails unknown from the extension")
+	statePath     = flag.String("state", "", "state file to record first seen time of entries")
+	archivePath   = flag.String("archive", "", "archive file to keep entries removed by the site")
+	archiveMaxAge = flag.Duration("archive-max-age", 0, "how long removed entries are kept (0: forever)")
+	archiveMax    = flag.Int("archive-max-entries", 0, "max entries of each feed with archive (0: unlimited)")
+	fullText      = flag.Bool("full-text", false, "embed the body of episodes in entries (novel sites)")
+	bodyCachePath = flag.String("body-cache", "", "cache file of the body of episodes with -full-text")
+	maxBodies     = flag.Int("max-bodies", 10, "max bodies fetched in a request with -full-text (0: unlimited)")
+	clientFlags   = cli.RegisterClientFlags(flag.CommandLine, cli.ClientDefaults{Retries: 1, RetryMaxWait: 5 * time.Second})
+	logFlags      = cli.RegisterLogFlags(flag.CommandLine)
+)
+
+var client *siteloader.Client
+
+var store *siteloader.StateStore
+
+var archive *siteloader.Archive
+
+var bodyCache *siteloader.BodyCache
+
+var logger *slog.Logger
+
+var collector = metrics.New()
 
 func main() {
 	flag.Parse()
 
+	var err error
+	logger, err = logFlags.NewLogger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot create logger:%v\n", err)
+		os.Exit(2)
+	}
+
+	if *sitesPath != "" {
+		loaders, err := siteloader.LoadSiteDefinitions(*sitesPath)
+		if err != nil {
+			cli.Fatal(logger, "cannot load site definitions", "error", err)
+		}
+		for _, l := range loaders {
+			siteloader.DefaultRegistry.Replace(l)
+		}
+	}
+
+	if err := cli.DisableSites(siteloader.DefaultRegistry, *disabledSites); err != nil {
+		cli.Fatal(logger, "cannot disable site", "error", err)
+	}
+
+	client, err = clientFlags.NewClient()
+	if err != nil {
+		cli.Fatal(logger, "cannot create HTTP client", "error", err)
+	}
+
+	if *statePath != "" {
+		store, err = siteloader.OpenStateStore(*statePath)
+		if err != nil {
+			cli.Fatal(logger, "cannot open state", "error", err)
+		}
+	}
+
+	if *archivePath != "" {
+		archive, err = siteloader.OpenArchive(*archivePath, siteloader.ArchiveRetention{
+			MaxAge:     *archiveMaxAge,
+			MaxEntries: *archiveMax,
+		})
+		if err != nil {
+			cli.Fatal(logger, "cannot open archive", "error", err)
+		}
+	}
+
+	if *fullText && *bodyCachePath != "" {
+		bodyCache, err = siteloader.OpenBodyCache(*bodyCachePath)
+		if err != nil {
+			cli.Fatal(logger, "cannot open body cache", "error", err)
+		}
+	}
+
 	// default router NOT remains double slashes.
 	r := mux.NewRouter().SkipClean(true)
 	r.PathPrefix("/entry/").HandlerFunc(handleEntry)
+	r.PathPrefix("/rss/").Handler(feedHandler("/rss/", siteloader.FormatRSS))
+	r.PathPrefix("/json/").Handler(feedHandler("/json/", siteloader.FormatJSON))
+	r.Handle("/metrics", collector)
+	r.Use(logRequest)
 
-	fmt.Printf("server starting at %s\n", *listener)
-	fmt.Printf("server shutting down:%+v", http.ListenAndServe(*listener, r))
+	logger.Info("server starting", "listener", *listener)
+	logger.Error("server shutting down", "error", http.ListenAndServe(*listener, r))
 }
 
+// handleEntry serves the feed in the format of the format query parameter,
+// or negotiated by Accept. The parameter is removed from the target URL.
 func handleEntry(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+
+	target := *r.URL
+	rawQuery, formatName, found := removeQueryParam(target.RawQuery, "format")
+	target.RawQuery = rawQuery
+
+	format := siteloader.NegotiateFormat(r.Header.Get("Accept"))
+	if found {
+		var err error
+		format, err = siteloader.ParseFormat(formatName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	handleFeed(w, r, strings.TrimPrefix(target.String(), "/entry/"), format)
+}
+
+// removeQueryParam removes the parameter name from rawQuery keeping the others as is.
+// returns the value of the first one and whether it is found.
+func removeQueryParam(rawQuery, name string) (string, string, bool) {
+	if rawQuery == "" {
+		return rawQuery, "", false
+	}
+
+	var kept []string
+	value, found := "", false
+	for _, param := range strings.Split(rawQuery, "&") {
+		k, v, _ := strings.Cut(param, "=")
+		if key, err := url.QueryUnescape(k); err == nil && key == name {
+			if !found {
+				value, _ = url.QueryUnescape(v)
+				found = true
+			}
+			continue
+		}
+		kept = append(kept, param)
+	}
+	return strings.Join(kept, "&"), value, found
+}
+
+// feedHandler serves the feed of the URL following prefix in format.
+func feedHandler(prefix string, format siteloader.Format) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleFeed(w, r, strings.TrimPrefix(r.URL.String(), prefix), format)
+	}
+}
+
+func handleFeed(w http.ResponseWriter, r *http.Request, rawuri string, format siteloader.Format) {
 
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	rawuri := strings.TrimPrefix(r.URL.String(), "/entry/")
 	if rawuri == "" {
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
-	fmt.Printf("target:%s\n", rawuri)
+	reqLogger := logger.With("target", rawuri)
 
-	ctx := siteloader.SetIfNoneMatch(r.Context(), r.Header.Get("If-None-Match"))
-	ctx = siteloader.SetIfModifiedSince(ctx, r.Header.Get("If-Modified-Since"))
+	// siteloader adds the target to its own log lines.
+	ctx := siteloader.SetLogger(r.Context(), logger)
+	ctx = siteloader.SetHooks(ctx, collector.Hooks())
+	ctx = siteloader.SetProbeMedia(ctx, *probeMedia)
+	if store != nil {
+		ctx = siteloader.SetStateStore(ctx, store)
+	}
+	if archive != nil {
+		ctx = siteloader.SetArchive(ctx, archive)
+	}
+	if *fullText {
+		ctx = siteloader.SetFullText(ctx, bodyCache, *maxBodies)
+	}
 
-	_, feed, metadata, err := siteloader.GetFeed(ctx, rawuri)
+	series, metadata, err := siteloader.GetSeriesWithOptions(ctx, rawuri,
+		siteloader.WithClient(client),
+		siteloader.WithIfNoneMatch(seriesETags(r.Header.Get("If-None-Match"), format)),
+		siteloader.WithIfModifiedSince(r.Header.Get("If-Modified-Since")),
+	)
+	if store != nil {
+		if err := store.Save(); err != nil {
+			reqLogger.Error("cannot save state", "error", err)
+		}
+	}
+	if archive != nil {
+		if err := archive.Save(); err != nil {
+			reqLogger.Error("cannot save archive", "error", err)
+		}
+	}
+	if bodyCache != nil {
+		if err := bodyCache.Save(); err != nil {
+			reqLogger.Error("cannot save body cache", "error", err)
+		}
+	}
 	if err != nil {
 
 		if errors.Is(err, siteloader.ErrNotModified) {
+			setValidators(w, metadata, format)
 			w.WriteHeader(http.StatusNotModified)
 			return
 		}
 
-		fmt.Printf("GetFeed error:%+v\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		reqLogger.Warn("GetSeries error",
+			"error_class", siteloader.ErrorClass(err),
+			"error", err,
+		)
+		http.Error(w, err.Error(), statusForError(err))
 		return
 	}
 
-	feedXml, err := feed.ToAtom()
+	feedData, err := siteloader.Render(series, format, *legacyIDs)
 	if err != nil {
-		fmt.Printf("ToAtom error:%+v\n", err)
+		reqLogger.Error("Render error", "format", format, "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/atom+xml")
+	w.Header().Set("Content-Type", format.ContentType())
+	setValidators(w, metadata, format)
+
+	fmt.Fprint(w, feedData)
+
+}
+
+// statusRecorder records the status and bytes of the response to log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
 
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+func logRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		logger.Info("request",
+			"method", r.Method,
+			"path", r.URL.String(),
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"duration", time.Since(started),
+		)
+	})
+}
+
+func setValidators(w http.ResponseWriter, metadata siteloader.HttpMetadata, format siteloader.Format) {
 	if metadata.LastModified != "" {
 		w.Header().Set("Last-Modified", metadata.LastModified)
 	}
 	if metadata.ETag != "" {
-		w.Header().Set("ETag", metadata.ETag)
+		w.Header().Set("ETag", variantETag(metadata.ETag, format))
+	}
+}
+
+// variantETag makes the ETag of the series distinct for each format,
+// as the representations of /entry/ share the URL.
+func variantETag(etag string, format siteloader.Format) string {
+	if format == siteloader.FormatAtom {
+		return etag
 	}
+	return strings.TrimSuffix(etag, `"`) + "+" + string(format) + `"`
+}
+
+// seriesETags reverts variantETag in If-None-Match. ETags of the other formats are removed.
+func seriesETags(ifNoneMatch string, format siteloader.Format) string {
+	if ifNoneMatch == "" || strings.TrimSpace(ifNoneMatch) == "*" {
+		return ifNoneMatch
+	}
+
+	var etags []string
+	for _, etag := range strings.Split(ifNoneMatch, ",") {
+		etag = strings.TrimSpace(etag)
+		base, variant := etag, siteloader.FormatAtom
+		if i := strings.LastIndex(etag, "+"); i >= 0 && strings.HasSuffix(etag, `"`) {
+			if f, err := siteloader.ParseFormat(etag[i+1 : len(etag)-1]); err == nil {
+				base, variant = etag[:i]+`"`, f
+			}
+		}
+		if variant == format {
+			etags = append(etags, base)
+		}
+	}
+	return strings.Join(etags, ", ")
+}
 
-	fmt.Fprint(w, feedXml)
+// statusForError returns the HTTP status code for the error from GetFeed.
+func statusForError(err error) int {
+	var unsupported *siteloader.UnsupportedSiteError
+	var option *siteloader.UnsupportedOptionError
+	var noEpisodes *siteloader.NoEpisodesError
+	var robots *siteloader.RobotsDisallowedError
+	var status *siteloader.HTTPStatusError
+	var layout *siteloader.LayoutError
 
+	switch {
+	case errors.As(err, &unsupported), errors.As(err, &option):
+		return http.StatusBadRequest
+	case errors.As(err, &noEpisodes):
+		return http.StatusNotFound
+	case errors.As(err, &robots):
+		return http.StatusForbidden
+	case errors.As(err, &status):
+		if status.StatusCode == http.StatusNotFound || status.StatusCode == http.StatusGone {
+			return http.StatusNotFound
+		}
+		return http.StatusBadGateway
+	case errors.As(err, &layout):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -0,0 +1,157 @@
+// Package cli holds the flags and helpers shared by the commands.
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/walkure/comic2atom/siteloader"
+)
+
+// ClientDefaults are the defaults of the client flags which differ between the commands.
+type ClientDefaults struct {
+	Retries      int
+	RetryMaxWait time.Duration
+}
+
+// ClientFlags are the flags of the HTTP client.
+type ClientFlags struct {
+	timeout      *time.Duration
+	httpProxy    *string
+	userAgent    *string
+	retries      *int
+	retryWait    *time.Duration
+	retryMaxWait *time.Duration
+	hostInterval *time.Duration
+	hostBurst    *int
+	obeyRobots   *bool
+	robotsTTL    *time.Duration
+}
+
+// RegisterClientFlags registers the flags of the HTTP client to fs.
+func RegisterClientFlags(fs *flag.FlagSet, defaults ClientDefaults) *ClientFlags {
+	return &ClientFlags{
+		timeout:      fs.Duration("timeout", 30*time.Second, "HTTP request timeout"),
+		httpProxy:    fs.String("proxy", "", "HTTP proxy URL (default: from environment)"),
+		userAgent:    fs.String("user-agent", siteloader.DefaultClient.UserAgent, "User-Agent header"),
+		retries:      fs.Int("retries", defaults.Retries, "max retries of failed HTTP request"),
+		retryWait:    fs.Duration("retry-wait", time.Second, "initial wait before retry (doubled each retry)"),
+		retryMaxWait: fs.Duration("retry-max-wait", defaults.RetryMaxWait, "max wait before retry"),
+		hostInterval: fs.Duration("host-interval", time.Second, "min interval of requests to the same host"),
+		hostBurst:    fs.Int("host-burst", 1, "requests to the same host allowed without waiting"),
+		obeyRobots:   fs.Bool("robots", false, "obey robots.txt"),
+		robotsTTL:    fs.Duration("robots-ttl", 24*time.Hour, "robots.txt cache duration"),
+	}
+}
+
+// NewClient returns the client configured by the flags.
+func (f *ClientFlags) NewClient() (*siteloader.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if *f.httpProxy != "" {
+		proxyURL, err := url.Parse(*f.httpProxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	var robots *siteloader.RobotsPolicy
+	if *f.obeyRobots {
+		robots = siteloader.NewRobotsPolicy(*f.robotsTTL)
+	}
+
+	return &siteloader.Client{
+		HTTP:      &http.Client{Transport: transport, Timeout: *f.timeout},
+		UserAgent: *f.userAgent,
+		Retry: siteloader.RetryPolicy{
+			MaxRetries: *f.retries,
+			BaseDelay:  *f.retryWait,
+			MaxDelay:   *f.retryMaxWait,
+		},
+		Limiter: siteloader.NewHostLimiter(*f.hostInterval, *f.hostBurst),
+		Robots:  robots,
+	}, nil
+}
+
+// LogFlags are the flags of the logger.
+type LogFlags struct {
+	format *string
+	level  *string
+}
+
+// RegisterLogFlags registers the flags of the logger to fs.
+func RegisterLogFlags(fs *flag.FlagSet) *LogFlags {
+	return &LogFlags{
+		format: fs.String("log-format", "text", "log format (text|json)"),
+		level:  fs.String("log-level", "info", "log level (debug|info|warn|error)"),
+	}
+}
+
+// NewLogger returns the logger to stdout configured by the flags.
+func (f *LogFlags) NewLogger() (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*f.level)); err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch *f.format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stdout, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stdout, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format: %s", *f.format)
+	}
+}
+
+// DisableSites disables the comma separated site names in r.
+func DisableSites(r *siteloader.Registry, names string) error {
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if err := r.Disable(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadList returns the lines of the list file except empty ones and comments.
+func LoadList(listPath string) ([]string, error) {
+	fp, err := os.Open(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file: %w", err)
+	}
+	defer fp.Close()
+
+	scanner := bufio.NewScanner(fp)
+	var list []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" && line[0] != '#' {
+			list = append(list, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+
+	return list, nil
+}
+
+// Fatal logs msg as an error and exits.
+func Fatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/walkure/comic2atom/siteloader"
+)
+
+func TestClientFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := RegisterClientFlags(fs, ClientDefaults{Retries: 1, RetryMaxWait: 5 * time.Second})
+	assert.Nil(t, fs.Parse([]string{"-retry-wait", "2s", "-robots"}))
+
+	client, err := f.NewClient()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, client.Retry.MaxRetries)
+	assert.Equal(t, 2*time.Second, client.Retry.BaseDelay)
+	assert.Equal(t, 5*time.Second, client.Retry.MaxDelay)
+	assert.Equal(t, 30*time.Second, client.HTTP.(*http.Client).Timeout)
+	assert.NotNil(t, client.Robots)
+
+	assert.Nil(t, fs.Parse([]string{"-proxy", "://invalid"}))
+	_, err = f.NewClient()
+	assert.NotNil(t, err)
+}
+
+func TestLogFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := RegisterLogFlags(fs)
+	assert.Nil(t, fs.Parse([]string{"-log-level", "warn", "-log-format", "json"}))
+
+	logger, err := f.NewLogger()
+	assert.Nil(t, err)
+	assert.False(t, logger.Enabled(context.Background(), -4))
+	assert.True(t, logger.Enabled(context.Background(), 4))
+
+	assert.Nil(t, fs.Parse([]string{"-log-format", "xml"}))
+	_, err = f.NewLogger()
+	assert.NotNil(t, err)
+}
+
+func TestDisableSites(t *testing.T) {
+	r := siteloader.NewRegistry()
+	for _, name := range []string{"a", "b", "c"} {
+		assert.Nil(t, r.Register(siteloader.NewPrefixLoader(name, "https://"+name+".example.com/", nil)))
+	}
+
+	assert.Nil(t, DisableSites(r, ""))
+	assert.Nil(t, DisableSites(r, "a, c,"))
+	for _, site := range r.Sites() {
+		assert.Equal(t, site.Name == "b", site.Enabled, site.Name)
+	}
+	_, ok := r.Lookup(&url.URL{Scheme: "https", Host: "b.example.com", Path: "/"})
+	assert.True(t, ok)
+
+	assert.NotNil(t, DisableSites(r, "d"))
+}
+
+func TestLoadList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("https://example.com/1\n\n# comment\nhttps://example.com/2 atom,rss\n"), 0644))
+
+	list, err := LoadList(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://example.com/1", "https://example.com/2 atom,rss"}, list)
+
+	_, err = LoadList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,377 @@
+// Package epub writes EPUB 3 books of novels in vertical writing.
+package epub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Book is an EPUB 3 book.
+type Book struct {
+	// Identifier is unique among books. (e.g. tag: URI of the series)
+	Identifier  string
+	Title       string
+	Author      string
+	Description string
+	// Language is the BCP 47 language tag. "ja" if empty.
+	Language string
+	// Source is the URL of the work.
+	Source   string
+	Modified time.Time
+	// Version is recorded in the package document and read by ReadVersion
+	// to tell whether the book should be rebuilt. not recorded if empty.
+	Version  string
+	Sections []Section
+}
+
+// Section is an episode of Book.
+type Section struct {
+	// Chapter is the name of the chapter group. Sections of the same chapter must be adjacent.
+	Chapter string
+	Title   string
+	// Link is the URL of the episode, shown instead of the body if Body is empty.
+	Link string
+	// Body is an HTML fragment. It is converted to XHTML, and images are replaced
+	// with the alternative text as EPUB allows no remote images.
+	Body string
+}
+
+const (
+	containerPath = "META-INF/container.xml"
+	packagePath   = "OEBPS/content.opf"
+)
+
+// versionProperty is the meta property of Book.Version.
+const versionProperty = "comic2atom:version"
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="` + packagePath + `" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const styleCSS = `@charset "UTF-8";
+html {
+  writing-mode: vertical-rl;
+  -webkit-writing-mode: vertical-rl;
+  -epub-writing-mode: vertical-rl;
+}
+body {
+  font-family: serif;
+  line-height: 1.8;
+}
+h1, h2 {
+  font-size: 1.2em;
+  margin: 0 0 0 2em;
+}
+p {
+  margin: 0;
+}
+rt {
+  font-size: 0.5em;
+}
+.description {
+  margin: 0 0 0 2em;
+}
+.missing {
+  color: gray;
+}
+`
+
+// Write writes the book as an EPUB file.
+func (b *Book) Write(w io.Writer) error {
+	z := zip.NewWriter(w)
+
+	// mimetype must be the first file without compression.
+	f, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name    string
+		content func() (string, error)
+	}{
+		{containerPath, func() (string, error) { return containerXML, nil }},
+		{packagePath, b.packageDocument},
+		{"OEBPS/style.css", func() (string, error) { return styleCSS, nil }},
+		{"OEBPS/title.xhtml", b.titlePage},
+		{"OEBPS/nav.xhtml", b.navDocument},
+	}
+	for i := range b.Sections {
+		files = append(files, struct {
+			name    string
+			content func() (string, error)
+		}{"OEBPS/" + sectionFile(i), func() (string, error) { return b.sectionPage(i) }})
+	}
+
+	for _, file := range files {
+		content, err := file.content()
+		if err != nil {
+			return fmt.Errorf("cannot render %s:%w", file.name, err)
+		}
+		f, err := z.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: b.Modified})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, content); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+// WriteFile writes the book to path atomically not to leave a broken book.
+func (b *Book) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := b.Write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write book: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot change mode: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write book: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cannot rename book: %w", err)
+	}
+	return nil
+}
+
+// ReadVersion returns Book.Version recorded in the EPUB file at path.
+// The error wraps fs.ErrNotExist if the file does not exist.
+func ReadVersion(path string) (string, error) {
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer z.Close()
+
+	f, err := z.Open(packagePath)
+	if err != nil {
+		return "", fmt.Errorf("cannot open package document:%w", err)
+	}
+	defer f.Close()
+
+	var pkg struct {
+		Metas []struct {
+			Property string `xml:"property,attr"`
+			Value    string `xml:",chardata"`
+		} `xml:"metadata>meta"`
+	}
+	if err := xml.NewDecoder(f).Decode(&pkg); err != nil {
+		return "", fmt.Errorf("cannot parse package document:%w", err)
+	}
+	for _, meta := range pkg.Metas {
+		if meta.Property == versionProperty {
+			return meta.Value, nil
+		}
+	}
+	return "", nil
+}
+
+func (b *Book) language() string {
+	if b.Language == "" {
+		return "ja"
+	}
+	return b.Language
+}
+
+func sectionFile(i int) string {
+	return fmt.Sprintf("s%04d.xhtml", i+1)
+}
+
+// escape escapes s as the text or an attribute value of XML.
+func escape(s string) string {
+	return html.EscapeString(s)
+}
+
+func (b *Book) packageDocument() (string, error) {
+	var s strings.Builder
+	s.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid" xml:lang="` + escape(b.language()) + `" prefix="comic2atom: https://github.com/walkure/comic2atom#">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&s, "    <dc:identifier id=\"bookid\">%s</dc:identifier>\n", escape(b.Identifier))
+	fmt.Fprintf(&s, "    <dc:title>%s</dc:title>\n", escape(b.Title))
+	if b.Author != "" {
+		fmt.Fprintf(&s, "    <dc:creator>%s</dc:creator>\n", escape(b.Author))
+	}
+	if b.Description != "" {
+		fmt.Fprintf(&s, "    <dc:description>%s</dc:description>\n", escape(b.Description))
+	}
+	fmt.Fprintf(&s, "    <dc:language>%s</dc:language>\n", escape(b.language()))
+	if b.Source != "" {
+		fmt.Fprintf(&s, "    <dc:source>%s</dc:source>\n", escape(b.Source))
+	}
+	fmt.Fprintf(&s, "    <meta property=\"dcterms:modified\">%s</meta>\n", b.Modified.UTC().Format("2006-01-02T15:04:05Z"))
+	if b.Version != "" {
+		fmt.Fprintf(&s, "    <meta property=\"%s\">%s</meta>\n", versionProperty, escape(b.Version))
+	}
+	s.WriteString(`  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+    <item id="title" href="title.xhtml" media-type="application/xhtml+xml"/>
+`)
+	for i := range b.Sections {
+		fmt.Fprintf(&s, "    <item id=\"s%04d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, sectionFile(i))
+	}
+	s.WriteString(`  </manifest>
+  <spine page-progression-direction="rtl">
+    <itemref idref="title"/>
+    <itemref idref="nav"/>
+`)
+	for i := range b.Sections {
+		fmt.Fprintf(&s, "    <itemref idref=\"s%04d\"/>\n", i+1)
+	}
+	s.WriteString("  </spine>\n</package>\n")
+	return s.String(), nil
+}
+
+// xhtml renders an XHTML document with body.
+func (b *Book) xhtml(title, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + escape(b.language()) + `" lang="` + escape(b.language()) + `">
+<head>
+<meta charset="UTF-8"/>
+<title>` + escape(title) + `</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+` + body + `
+</body>
+</html>
+`
+}
+
+func (b *Book) titlePage() (string, error) {
+	var s strings.Builder
+	fmt.Fprintf(&s, "<h1>%s</h1>\n", escape(b.Title))
+	if b.Author != "" {
+		fmt.Fprintf(&s, "<p>%s</p>\n", escape(b.Author))
+	}
+	if b.Description != "" {
+		s.WriteString("<div class=\"description\">\n")
+		for _, line := range strings.Split(b.Description, "\n") {
+			if line == "" {
+				s.WriteString("<p><br/></p>\n")
+				continue
+			}
+			fmt.Fprintf(&s, "<p>%s</p>\n", escape(line))
+		}
+		s.WriteString("</div>")
+	}
+	return b.xhtml(b.Title, s.String()), nil
+}
+
+func (b *Book) navDocument() (string, error) {
+	var s strings.Builder
+	s.WriteString("<nav epub:type=\"toc\" id=\"toc\">\n<h1>目次</h1>\n<ol>\n")
+	chapter := ""
+	for i, sec := range b.Sections {
+		if sec.Chapter != chapter {
+			if chapter != "" {
+				s.WriteString("</ol></li>\n")
+			}
+			if sec.Chapter != "" {
+				fmt.Fprintf(&s, "<li><span>%s</span><ol>\n", escape(sec.Chapter))
+			}
+			chapter = sec.Chapter
+		}
+		fmt.Fprintf(&s, "<li><a href=\"%s\">%s</a></li>\n", sectionFile(i), escape(sec.Title))
+	}
+	if chapter != "" {
+		s.WriteString("</ol></li>\n")
+	}
+	s.WriteString("</ol>\n</nav>")
+	return b.xhtml("目次", s.String()), nil
+}
+
+func (b *Book) sectionPage(i int) (string, error) {
+	sec := b.Sections[i]
+
+	var s strings.Builder
+	s.WriteString("<section epub:type=\"chapter\">\n")
+	// the chapter name at the first section of the chapter
+	if sec.Chapter != "" && (i == 0 || b.Sections[i-1].Chapter != sec.Chapter) {
+		fmt.Fprintf(&s, "<h1>%s</h1>\n", escape(sec.Chapter))
+	}
+	fmt.Fprintf(&s, "<h2>%s</h2>\n", escape(sec.Title))
+	if sec.Body == "" {
+		fmt.Fprintf(&s, "<p class=\"missing\">本文を取得できませんでした。<a href=\"%s\">%s</a></p>", escape(sec.Link), escape(sec.Link))
+	} else {
+		body, err := toXHTML(sec.Body)
+		if err != nil {
+			return "", err
+		}
+		s.WriteString(body)
+	}
+	s.WriteString("\n</section>")
+	return b.xhtml(sec.Title, s.String()), nil
+}
+
+// toXHTML renders the HTML fragment as XHTML without images.
+func toXHTML(fragment string) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return "", err
+	}
+
+	container := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, n := range nodes {
+		container.AppendChild(n)
+	}
+	replaceImages(container)
+
+	var s strings.Builder
+	for n := container.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(&s, n); err != nil {
+			return "", err
+		}
+	}
+	return s.String(), nil
+}
+
+// replaceImages replaces img elements under n with their alternative text.
+func replaceImages(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && c.DataAtom == atom.Img {
+			for _, attr := range c.Attr {
+				if attr.Key == "alt" && attr.Val != "" {
+					n.InsertBefore(&html.Node{Type: html.TextNode, Data: "[" + attr.Val + "]"}, c)
+				}
+			}
+			n.RemoveChild(c)
+		} else {
+			replaceImages(c)
+		}
+		c = next
+	}
+}
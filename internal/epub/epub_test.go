@@ -0,0 +1,115 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readFile(t *testing.T, z *zip.ReadCloser, name string) string {
+	f, err := z.Open(name)
+	if err != nil {
+		t.Fatalf("cannot open %s:%v", name, err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("cannot read %s:%v", name, err)
+	}
+	return string(b)
+}
+
+// wellFormed reports whether s is well-formed XML.
+func wellFormed(s string) error {
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func TestBook(t *testing.T) {
+	book := &Book{
+		Identifier:  "tag:3pf.jp,2024:comic2atom/narou_n0000a",
+		Title:       "テスト<タイトル>",
+		Author:      "テスト著者",
+		Description: "テスト\n\nストーリー",
+		Source:      "https://ncode.syosetu.com/n0000a/",
+		Modified:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Version:     `"abc"`,
+		Sections: []Section{
+			{Title: "プロローグ", Body: "<p>はじまり</p>"},
+			{Chapter: "第一章", Title: "サブタイトル1", Body: `<p><ruby>漢字<rp>(</rp><rt>かんじ</rt><rp>)</rp></ruby>です。</p><p><br></p><img src="https://example.com/1.png" alt="挿絵">`},
+			{Chapter: "第一章", Title: "サブタイトル2", Link: "https://ncode.syosetu.com/n0000a/3/"},
+			{Chapter: "第二章", Title: "サブタイトル3", Body: "<p>本文 &amp; 本文"},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "book.epub")
+	assert.Nil(t, book.WriteFile(path))
+
+	z, err := zip.OpenReader(path)
+	assert.Nil(t, err)
+	defer z.Close()
+
+	assert.Equal(t, "mimetype", z.File[0].Name)
+	assert.Equal(t, zip.Store, z.File[0].Method)
+	assert.Equal(t, "application/epub+zip", readFile(t, z, "mimetype"))
+
+	for _, f := range z.File[1:] {
+		if strings.HasSuffix(f.Name, ".css") {
+			continue
+		}
+		assert.Nil(t, wellFormed(readFile(t, z, f.Name)), f.Name)
+	}
+
+	opf := readFile(t, z, "OEBPS/content.opf")
+	assert.Contains(t, opf, `<dc:title>テスト&lt;タイトル&gt;</dc:title>`)
+	assert.Contains(t, opf, `<dc:creator>テスト著者</dc:creator>`)
+	assert.Contains(t, opf, `<dc:language>ja</dc:language>`)
+	assert.Contains(t, opf, `<meta property="dcterms:modified">2024-01-02T03:04:05Z</meta>`)
+	assert.Contains(t, opf, `<spine page-progression-direction="rtl">`)
+	assert.Contains(t, opf, `<itemref idref="s0004"/>`)
+
+	nav := readFile(t, z, "OEBPS/nav.xhtml")
+	assert.Contains(t, nav, `<ol>
+<li><a href="s0001.xhtml">プロローグ</a></li>
+<li><span>第一章</span><ol>
+<li><a href="s0002.xhtml">サブタイトル1</a></li>
+<li><a href="s0003.xhtml">サブタイトル2</a></li>
+</ol></li>
+<li><span>第二章</span><ol>
+<li><a href="s0004.xhtml">サブタイトル3</a></li>
+</ol></li>
+</ol>`)
+
+	assert.Contains(t, readFile(t, z, "OEBPS/style.css"), "writing-mode: vertical-rl;")
+
+	first := readFile(t, z, "OEBPS/s0002.xhtml")
+	assert.Contains(t, first, "<h1>第一章</h1>\n<h2>サブタイトル1</h2>\n"+
+		`<p><ruby>漢字<rp>(</rp><rt>かんじ</rt><rp>)</rp></ruby>です。</p><p><br/></p>[挿絵]`)
+
+	second := readFile(t, z, "OEBPS/s0003.xhtml")
+	assert.NotContains(t, second, "<h1>")
+	assert.Contains(t, second, `<a href="https://ncode.syosetu.com/n0000a/3/">`)
+
+	version, err := ReadVersion(path)
+	assert.Nil(t, err)
+	assert.Equal(t, `"abc"`, version)
+
+	_, err = ReadVersion(filepath.Join(t.TempDir(), "missing.epub"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
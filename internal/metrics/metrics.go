@@ -0,0 +1,202 @@
+// Package metrics collects the events of siteloader in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/walkure/comic2atom/siteloader"
+)
+
+type sample struct {
+	// suffix is appended to the family name. (e.g. "_sum")
+	suffix string
+	labels string
+}
+
+type family struct {
+	name   string
+	help   string
+	typ    string
+	values map[sample]float64
+}
+
+func (f *family) add(suffix string, v float64, labels ...string) {
+	f.values[sample{suffix: suffix, labels: formatLabels(labels)}] += v
+}
+
+// formatLabels formats name/value pairs as {name="value",...}.
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+// Collector counts the events reported to its Hooks.
+type Collector struct {
+	mu            sync.Mutex
+	fetches       *family
+	fetchInFlight *family
+	fetchBytes    *family
+	fetchDuration *family
+	loads         *family
+	loadDuration  *family
+	items         *family
+}
+
+// New returns an empty Collector.
+func New() *Collector {
+	newFamily := func(name, typ, help string) *family {
+		return &family{name: name, typ: typ, help: help, values: map[sample]float64{}}
+	}
+	return &Collector{
+		fetches:       newFamily("comic2atom_fetch_requests_total", "counter", "HTTP requests by site and status (error if no response)."),
+		fetchInFlight: newFamily("comic2atom_fetch_in_flight", "gauge", "HTTP requests in flight by site."),
+		fetchBytes:    newFamily("comic2atom_fetch_bytes_total", "counter", "Bytes of response bodies read by site."),
+		fetchDuration: newFamily("comic2atom_fetch_duration_seconds", "summary", "Duration of HTTP requests by site."),
+		loads:         newFamily("comic2atom_loads_total", "counter", "Loads of series by site and result (ok, not_modified or error class)."),
+		loadDuration:  newFamily("comic2atom_load_duration_seconds", "summary", "Duration of loads by site."),
+		items:         newFamily("comic2atom_items_total", "counter", "Items of feeds generated by site."),
+	}
+}
+
+func (c *Collector) families() []*family {
+	return []*family{c.fetches, c.fetchInFlight, c.fetchBytes, c.fetchDuration, c.loads, c.loadDuration, c.items}
+}
+
+// Hooks returns the hooks to pass to siteloader.SetHooks or siteloader.WithHooks.
+func (c *Collector) Hooks() *siteloader.Hooks {
+	return &siteloader.Hooks{
+		FetchStart: c.fetchStart,
+		FetchEnd:   c.fetchEnd,
+		Load:       c.load,
+	}
+}
+
+func (c *Collector) fetchStart(ev siteloader.FetchStartEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fetchInFlight.add("", 1, "site", ev.Site)
+}
+
+func (c *Collector) fetchEnd(ev siteloader.FetchEndEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := "error"
+	if ev.Status != 0 {
+		status = strconv.Itoa(ev.Status)
+	}
+
+	c.fetchInFlight.add("", -1, "site", ev.Site)
+	c.fetches.add("", 1, "site", ev.Site, "status", status)
+	c.fetchBytes.add("", float64(ev.Bytes), "site", ev.Site)
+	c.fetchDuration.add("_sum", ev.Duration.Seconds(), "site", ev.Site)
+	c.fetchDuration.add("_count", 1, "site", ev.Site)
+}
+
+func (c *Collector) load(ev siteloader.LoadEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := "ok"
+	switch {
+	case ev.NotModified:
+		result = "not_modified"
+	case ev.Err != nil:
+		result = siteloader.ErrorClass(ev.Err)
+	}
+
+	c.loads.add("", 1, "site", ev.Site, "result", result)
+	c.loadDuration.add("_sum", ev.Duration.Seconds(), "site", ev.Site)
+	c.loadDuration.add("_count", 1, "site", ev.Site)
+	c.items.add("", float64(ev.Items), "site", ev.Site)
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var b strings.Builder
+	for _, f := range c.families() {
+		if len(f.values) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.typ)
+
+		samples := make([]sample, 0, len(f.values))
+		for s := range f.values {
+			samples = append(samples, s)
+		}
+		slices.SortFunc(samples, func(x, y sample) int {
+			if x.labels != y.labels {
+				return strings.Compare(x.labels, y.labels)
+			}
+			return strings.Compare(x.suffix, y.suffix)
+		})
+		for _, s := range samples {
+			fmt.Fprintf(&b, "%s%s%s %s\n", f.name, s.suffix, s.labels, strconv.FormatFloat(f.values[s], 'g', -1, 64))
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics for Prometheus.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteFile writes the metrics to path for the textfile collector of node-exporter.
+// The file is replaced atomically not to be read while writing.
+func (c *Collector) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := c.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write metrics: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot change mode: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write metrics: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cannot rename metrics file: %w", err)
+	}
+	return nil
+}
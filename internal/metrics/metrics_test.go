@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/walkure/comic2atom/siteloader"
+)
+
+func TestCollector(t *testing.T) {
+	c := New()
+	hooks := c.Hooks()
+
+	hooks.FetchStart(siteloader.FetchStartEvent{Site: "fuz", URL: "https://comic-fuz.com/manga/1"})
+	hooks.FetchEnd(siteloader.FetchEndEvent{Site: "fuz", URL: "https://comic-fuz.com/manga/1", Status: 200, Bytes: 1024, Duration: 500 * time.Millisecond})
+	hooks.FetchStart(siteloader.FetchStartEvent{Site: "fuz", URL: "https://comic-fuz.com/manga/2"})
+	hooks.FetchEnd(siteloader.FetchEndEvent{Site: "fuz", URL: "https://comic-fuz.com/manga/2", Duration: time.Second, Err: errors.New("timeout")})
+	hooks.FetchStart(siteloader.FetchStartEvent{Site: "narou", URL: "https://ncode.syosetu.com/n0000a/"})
+
+	hooks.Load(siteloader.LoadEvent{Site: "fuz", Items: 3, Duration: 500 * time.Millisecond})
+	hooks.Load(siteloader.LoadEvent{Site: "fuz", NotModified: true, Duration: 250 * time.Millisecond})
+	hooks.Load(siteloader.LoadEvent{Site: "fuz", Err: &siteloader.LayoutError{Site: "fuz", Selector: "h1"}, Duration: 250 * time.Millisecond})
+
+	expected := `# HELP comic2atom_fetch_requests_total HTTP requests by site and status (error if no response).
+# TYPE comic2atom_fetch_requests_total counter
+comic2atom_fetch_requests_total{site="fuz",status="200"} 1
+comic2atom_fetch_requests_total{site="fuz",status="error"} 1
+# HELP comic2atom_fetch_in_flight HTTP requests in flight by site.
+# TYPE comic2atom_fetch_in_flight gauge
+comic2atom_fetch_in_flight{site="fuz"} 0
+comic2atom_fetch_in_flight{site="narou"} 1
+# HELP comic2atom_fetch_bytes_total Bytes of response bodies read by site.
+# TYPE comic2atom_fetch_bytes_total counter
+comic2atom_fetch_bytes_total{site="fuz"} 1024
+# HELP comic2atom_fetch_duration_seconds Duration of HTTP requests by site.
+# TYPE comic2atom_fetch_duration_seconds summary
+comic2atom_fetch_duration_seconds_count{site="fuz"} 2
+comic2atom_fetch_duration_seconds_sum{site="fuz"} 1.5
+# HELP comic2atom_loads_total Loads of series by site and result (ok, not_modified or error class).
+# TYPE comic2atom_loads_total counter
+comic2atom_loads_total{site="fuz",result="layout"} 1
+comic2atom_loads_total{site="fuz",result="not_modified"} 1
+comic2atom_loads_total{site="fuz",result="ok"} 1
+# HELP comic2atom_load_duration_seconds Duration of loads by site.
+# TYPE comic2atom_load_duration_seconds summary
+comic2atom_load_duration_seconds_count{site="fuz"} 3
+comic2atom_load_duration_seconds_sum{site="fuz"} 1
+# HELP comic2atom_items_total Items of feeds generated by site.
+# TYPE comic2atom_items_total counter
+comic2atom_items_total{site="fuz"} 3
+`
+
+	var b strings.Builder
+	_, err := c.WriteTo(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, b.String())
+
+	// endpoint
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, expected, rec.Body.String())
+
+	// textfile
+	path := filepath.Join(t.TempDir(), "comic2atom.prom")
+	assert.Nil(t, c.WriteFile(path))
+	written, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(written))
+}
+
+func TestFormatLabels(t *testing.T) {
+	assert.Equal(t, "", formatLabels(nil))
+	assert.Equal(t, `{site="a\"b\\c\nd"}`, formatLabels([]string{"site", "a\"b\\c\nd"}))
+}
@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/gorilla/feeds"
 )
 
 // alphapolisMOData represents the JSON structure embedded in the page
@@ -32,11 +31,17 @@ type alphapolisMORental struct {
 	FreeExpire *int64 `json:"freeExpire"`
 }
 
-func alphapolisMOFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMetadata, error) {
+func alphapolisMOFeed(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+	freeOnly := paidExcluded(ctx)
+
+	loc, err := siteLocation(ctx)
+	if err != nil {
+		return nil, HttpMetadata{}, fmt.Errorf("alphapolisMO:%w", err)
+	}
 
 	doc, metadata, err := fetchDocument(ctx, target)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("alphapolisMO:FetchErr:%w", err)
+		return nil, metadata, fmt.Errorf("alphapolisMO:FetchErr:%w", err)
 	}
 
 	title := doc.Find("meta[property='og:title']").AttrOr("content", "タイトル不明")
@@ -53,13 +58,20 @@ func alphapolisMOFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed
 	})
 	authorString := strings.Join(authors, " | ")
 
-	feed := &feeds.Feed{
+	// free-only feeds, the default, keep their key.
+	paidSuffix := ""
+	if !freeOnly {
+		paidSuffix = "_paid"
+	}
+
+	series := &Series{
+		Site:        "alphapolis",
+		Key:         "alphapolis_" + escapePath(target.Path) + paidSuffix,
+		ID:          generateHashedHex(link),
 		Title:       title,
-		Link:        &feeds.Link{Href: link},
+		Link:        link,
 		Description: description,
-		Author:      &feeds.Author{Name: authorString},
-		Created:     time.Now(),
-		Id:          generateHashedHex(link),
+		Author:      authorString,
 	}
 
 	// Find and parse JSON data from script tag
@@ -72,13 +84,16 @@ func alphapolisMOFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed
 	})
 
 	if len(episodesData.Episodes) == 0 {
-		return "", nil, metadata, fmt.Errorf("alphapolisMO:no episode data found")
+		return nil, metadata, &LayoutError{Site: "alphapolis", Selector: "#app-official-manga-toc script[type='application/json']"}
 	}
 
 	// Process episodes from JSON
 	for _, ep := range episodesData.Episodes {
-		// Skip non-free episodes
-		if !ep.Rental.IsFree {
+		pricing := PricingPaid
+		if ep.Rental.IsFree {
+			pricing = PricingFree
+		}
+		if freeOnly && pricing != PricingFree {
 			continue
 		}
 
@@ -92,43 +107,46 @@ func alphapolisMOFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed
 		if ep.UpTime != "" {
 			description = fmt.Sprintf("更新日: %s", ep.UpTime)
 		}
-		if ep.Rental.FreeExpire != nil {
-			expireTime := time.Unix(*ep.Rental.FreeExpire/1000, 0)
-			description = fmt.Sprintf("%sまで無料 (%s)", expireTime.Format("2006.01.02"), description)
-		} else {
+		var freeUntil time.Time
+		switch {
+		case pricing != PricingFree:
+			description = fmt.Sprintf("有料 (%s)", description)
+		case ep.Rental.FreeExpire != nil:
+			freeUntil = time.Unix(*ep.Rental.FreeExpire/1000, 0)
+			description = fmt.Sprintf("%sまで無料 (%s)", freeUntil.Format("2006.01.02"), description)
+		default:
 			description = fmt.Sprintf("無料 (%s)", description)
 		}
 
-		item := &feeds.Item{
+		item := &Episode{
+			ID:          generateHashedHex(eHref),
 			Title:       ep.ShortTitle,
-			Link:        &feeds.Link{Href: eHref},
+			Link:        eHref,
 			Description: description,
-			Created:     parseAPMCDate(ep.UpTime),
-			Id:          generateHashedHex(eHref),
-			Enclosure:   &feeds.Enclosure{Url: ep.ThumbnailURL},
+			Number:      ep.EpisodeNo,
+			Thumbnail:   ep.ThumbnailURL,
+			Pricing:     pricing,
+			FreeUntil:   freeUntil,
+			Created:     parseAPMCDate(ep.UpTime, loc),
 		}
-		feed.Items = append(feed.Items, item)
+		series.Episodes = append(series.Episodes, item)
 	}
 
-	if len(feed.Items) == 0 {
-		return "", nil, metadata, fmt.Errorf("alphapolisMO:no free episode entry")
+	if len(series.Episodes) == 0 {
+		return nil, metadata, &NoEpisodesError{Site: "alphapolis", URL: target.String()}
 	}
 
-	return "alphapolis_" + escapePath(target.Path), feed, metadata, nil
+	return series, metadata, nil
 }
 
-func parseAPMCDate(raw string) time.Time {
+// parseAPMCDate parses "2006.01.02更新". zero if not parsed, to be filled by StateStore.
+func parseAPMCDate(raw string, loc *time.Location) time.Time {
 	clean := strings.ReplaceAll(raw, "更新", "")
 	clean = strings.TrimSpace(clean)
 
-	loc, err := time.LoadLocation("Asia/Tokyo")
-	if err != nil {
-		return time.Time{}
-	}
-
 	t, err := time.ParseInLocation("2006.01.02", clean, loc)
 	if err != nil {
-		return time.Now()
+		return time.Time{}
 	}
 	return t
 }
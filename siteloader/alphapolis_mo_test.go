@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,9 +28,10 @@ func TestAlphapolisMangaOfficial(t *testing.T) {
 
 	testUrl, _ := url.Parse(testsv.URL + "/path_t/est")
 
-	fname, feed, _, err := alphapolisMOFeed(context.Background(), testUrl)
+	series, _, err := alphapolisMOFeed(context.Background(), testUrl)
 	assert.Nil(t, err)
-	assert.Equal(t, "alphapolis_path_test", fname)
+	feed := series.Feed()
+	assert.Equal(t, "alphapolis_path_test", series.Key)
 	assert.Equal(t, "テスト作品タイトル", feed.Title)
 	assert.Equal(t, "これは検証用のあらすじです。", feed.Description)
 	assert.Equal(t, "テスト著者/漫画 | テスト著者/原作", feed.Author.Name)
@@ -58,10 +60,66 @@ func TestAlphapolisMangaOfficial(t *testing.T) {
 		t.Run(tt.title, func(t *testing.T) {
 			absPath, _ := resolveRelativeURI(testUrl, tt.path)
 
-			assert.Equal(t, generateHashedHex(absPath), feed.Items[index].Id)
+			assert.Equal(t, generateHashedHex(absPath), series.Episodes[index].ID)
 			assert.Equal(t, absPath, feed.Items[index].Link.Href)
 			assert.Equal(t, tt.thumb, feed.Items[index].Enclosure.Url)
 			assert.Equal(t, tt.title, feed.Items[index].Title)
+			assert.Equal(t, PricingFree, series.Episodes[index].Pricing)
 		})
 	}
 }
+
+func TestAlphapolisMangaOfficialPaid(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := os.Open("./testdata/alphapolis_mo_test.html")
+		if err != nil {
+			t.Fatalf("Cannot load test file:%v", err)
+		}
+		defer f.Close()
+		io.Copy(w, f)
+	}))
+	defer testsv.Close()
+
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("alphapolis", testsv.URL, alphapolisMOFeed, SiteOptionFreeOnly, SiteOptionPaid, SiteOptionLocation)))
+
+	series, _, err := r.GetSeriesWithOptions(context.Background(), testsv.URL+"/path_t/est", WithPaid())
+	assert.Nil(t, err)
+	assert.Equal(t, "alphapolis_path_test_paid", series.Key)
+	// 第3話は有料
+	assert.Equal(t, 3, len(series.Episodes))
+	assert.Equal(t, PricingPaid, series.Episodes[2].Pricing)
+	assert.True(t, strings.HasPrefix(series.Episodes[2].Description, "有料 "))
+
+	// WithFreeOnly takes precedence
+	series, _, err = r.GetSeriesWithOptions(context.Background(), testsv.URL+"/path_t/est", WithPaid(), WithFreeOnly())
+	assert.Nil(t, err)
+	assert.Equal(t, "alphapolis_path_test", series.Key)
+	assert.Equal(t, 2, len(series.Episodes))
+}
+
+func TestAlphapolisMangaOfficialConditional(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := os.Open("./testdata/alphapolis_mo_test.html")
+		if err != nil {
+			t.Fatalf("Cannot load test file:%v", err)
+		}
+		defer f.Close()
+		io.Copy(w, f)
+	}))
+	defer testsv.Close()
+
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("alphapolis", testsv.URL, alphapolisMOFeed, SiteOptionFreeOnly, SiteOptionLocation)))
+
+	// the content does not depend on the time of the request.
+	_, first, err := r.GetSeries(context.Background(), testsv.URL+"/manga/official/456")
+	assert.Nil(t, err)
+	_, second, err := r.GetSeries(context.Background(), testsv.URL+"/manga/official/456")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, first.ETag)
+	assert.Equal(t, first.ETag, second.ETag)
+
+	_, _, err = r.GetSeriesWithOptions(context.Background(), testsv.URL+"/manga/official/456", WithIfNoneMatch(first.ETag))
+	assert.ErrorIs(t, err, ErrNotModified)
+}
@@ -0,0 +1,136 @@
+package siteloader
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// ArchiveRetention limits episodes kept by Archive after the site removed them.
+type ArchiveRetention struct {
+	// MaxAge is how long the episode is kept since it was last seen. zero means forever.
+	MaxAge time.Duration
+	// MaxEntries is the max number of episodes of each series including available ones.
+	// zero means unlimited.
+	MaxEntries int
+}
+
+type archivedEpisode struct {
+	Episode  *Episode  `json:"episode"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Archive keeps episodes once seen and merges them into the series
+// after the site removed them, marked as Unavailable.
+type Archive struct {
+	jsonFile[[]*archivedEpisode]
+	retention ArchiveRetention
+	now       func() time.Time
+}
+
+// OpenArchive loads the archive from path. It is empty if the file does not exist.
+func OpenArchive(path string, retention ArchiveRetention) (*Archive, error) {
+	a := &Archive{retention: retention, now: time.Now}
+	if err := a.load(path, "archive"); err != nil {
+		return nil, err
+	}
+	for key, entries := range a.series {
+		a.series[key] = slices.DeleteFunc(entries, func(ae *archivedEpisode) bool {
+			return ae == nil || ae.Episode == nil
+		})
+	}
+
+	return a, nil
+}
+
+// archiveSeenInterval is how often LastSeen of episodes still on the site is
+// updated, not to rewrite the archive on every fetch.
+const archiveSeenInterval = 24 * time.Hour
+
+// merge records the episodes of series and appends the archived ones
+// which are no longer in the series.
+func (a *Archive) merge(series *Series) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	// LastSeen is behind by up to the interval, which must not expire episodes early.
+	seenInterval := archiveSeenInterval
+	if a.retention.MaxAge > 0 {
+		seenInterval = min(seenInterval, a.retention.MaxAge/2)
+	}
+
+	archivedAt := make(map[string]time.Time, len(a.series[series.Key]))
+	for _, ae := range a.series[series.Key] {
+		archivedAt[episodeKey(ae.Episode)] = ae.LastSeen
+	}
+
+	changed := false
+	fetched := make(map[string]bool, len(series.Episodes))
+	entries := make([]*archivedEpisode, 0, len(series.Episodes))
+	for _, ep := range series.Episodes {
+		key := episodeKey(ep)
+		if fetched[key] {
+			continue
+		}
+		fetched[key] = true
+
+		lastSeen, ok := archivedAt[key]
+		if !ok || now.Sub(lastSeen) >= seenInterval {
+			lastSeen = now
+			changed = true
+		}
+
+		archived := *ep
+		archived.Unavailable = false
+		entries = append(entries, &archivedEpisode{Episode: &archived, LastSeen: lastSeen})
+	}
+
+	var removed []*archivedEpisode
+	for _, ae := range a.series[series.Key] {
+		if fetched[episodeKey(ae.Episode)] {
+			continue
+		}
+		if a.retention.MaxAge > 0 && now.Sub(ae.LastSeen) > a.retention.MaxAge {
+			changed = true
+			continue
+		}
+		removed = append(removed, ae)
+	}
+
+	// recently seen ones are kept.
+	slices.SortStableFunc(removed, func(x, y *archivedEpisode) int {
+		return y.LastSeen.Compare(x.LastSeen)
+	})
+	if a.retention.MaxEntries > 0 {
+		if kept := max(0, a.retention.MaxEntries-len(entries)); kept < len(removed) {
+			removed = removed[:kept]
+			changed = true
+		}
+	}
+
+	for _, ae := range removed {
+		ep := *ae.Episode
+		ep.Unavailable = true
+		series.Episodes = append(series.Episodes, &ep)
+	}
+
+	a.series[series.Key] = append(entries, removed...)
+	if changed {
+		a.dirty = true
+	}
+}
+
+type archiveType string
+
+const archiveKey = archiveType("archive")
+
+// SetArchive sets the Archive used by GetSeries and GetFeed.
+func SetArchive(ctx context.Context, archive *Archive) context.Context {
+	return context.WithValue(ctx, archiveKey, archive)
+}
+
+func getArchive(ctx context.Context) *Archive {
+	archive, _ := ctx.Value(archiveKey).(*Archive)
+	return archive
+}
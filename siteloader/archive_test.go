@@ -0,0 +1,162 @@
+package siteloader
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.json")
+
+	archive, err := OpenArchive(path, ArchiveRetention{})
+	assert.Nil(t, err)
+
+	first := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	archive.now = func() time.Time { return first }
+
+	series := &Series{
+		Key: "test_1",
+		Episodes: []*Episode{
+			{ID: "ep1", Title: "第1話"},
+			{ID: "ep2", Title: "第2話"},
+		},
+	}
+	archive.merge(series)
+	assert.Equal(t, 2, len(series.Episodes))
+	assert.Nil(t, archive.Save())
+
+	// ep1 is removed by the site
+	archive, err = OpenArchive(path, ArchiveRetention{})
+	assert.Nil(t, err)
+	archive.now = func() time.Time { return first.Add(24 * time.Hour) }
+
+	series = &Series{
+		Key: "test_1",
+		Episodes: []*Episode{
+			{ID: "ep2", Title: "第2話"},
+			{ID: "ep3", Title: "第3話"},
+		},
+	}
+	archive.merge(series)
+	assert.Equal(t, 3, len(series.Episodes))
+	assert.Equal(t, "ep1", series.Episodes[2].ID)
+	assert.Equal(t, "第1話", series.Episodes[2].Title)
+	assert.True(t, series.Episodes[2].Unavailable)
+	assert.False(t, series.Episodes[0].Unavailable)
+
+	feed := series.Feed()
+	assert.True(t, strings.HasPrefix(feed.Items[2].Description, "(公開終了)"))
+	rss, err := Render(series, FormatRSS, false)
+	assert.Nil(t, err)
+	assert.Contains(t, rss, "(公開終了) ")
+
+	// ep1 is back
+	series = &Series{
+		Key:      "test_1",
+		Episodes: []*Episode{{ID: "ep1", Title: "第1話"}},
+	}
+	archive.merge(series)
+	assert.Equal(t, 3, len(series.Episodes))
+	assert.False(t, series.Episodes[0].Unavailable)
+	assert.True(t, series.Episodes[1].Unavailable)
+	assert.True(t, series.Episodes[2].Unavailable)
+
+	// other series is not affected
+	series = &Series{Key: "test_2", Episodes: []*Episode{{ID: "ep1"}}}
+	archive.merge(series)
+	assert.Equal(t, 1, len(series.Episodes))
+}
+
+func TestArchiveRetention(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	archive, err := OpenArchive(filepath.Join(t.TempDir(), "archive.json"), ArchiveRetention{MaxAge: 84 * time.Hour, MaxEntries: 3})
+	assert.Nil(t, err)
+	archive.now = func() time.Time { return now }
+
+	for _, id := range []string{"ep1", "ep2", "ep3", "ep4"} {
+		archive.merge(&Series{Key: "test_1", Episodes: []*Episode{{ID: id}}})
+		now = now.Add(24 * time.Hour)
+	}
+
+	// ep1 is too old and ep2 exceeds MaxEntries
+	series := &Series{Key: "test_1", Episodes: []*Episode{{ID: "ep5"}}}
+	archive.merge(series)
+	ids := []string{}
+	for _, ep := range series.Episodes {
+		ids = append(ids, ep.ID)
+	}
+	assert.Equal(t, []string{"ep5", "ep4", "ep3"}, ids)
+}
+
+func TestArchiveDirty(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	archive, err := OpenArchive(filepath.Join(t.TempDir(), "archive.json"), ArchiveRetention{MaxAge: 72 * time.Hour})
+	assert.Nil(t, err)
+	archive.now = func() time.Time { return now }
+
+	archive.merge(&Series{Key: "test_1", Episodes: []*Episode{{ID: "ep1"}, {ID: "ep2"}}})
+	assert.True(t, archive.dirty)
+	assert.Nil(t, archive.Save())
+
+	// the same episodes within the interval
+	now = now.Add(time.Hour)
+	archive.merge(&Series{Key: "test_1", Episodes: []*Episode{{ID: "ep2"}, {ID: "ep1"}}})
+	assert.False(t, archive.dirty)
+
+	// removed by the site, but still archived
+	archive.merge(&Series{Key: "test_1", Episodes: []*Episode{{ID: "ep2"}}})
+	assert.False(t, archive.dirty)
+
+	// LastSeen of ep2 is updated after the interval
+	now = now.Add(archiveSeenInterval)
+	archive.merge(&Series{Key: "test_1", Episodes: []*Episode{{ID: "ep2"}}})
+	assert.True(t, archive.dirty)
+	assert.Nil(t, archive.Save())
+
+	// new episode
+	archive.merge(&Series{Key: "test_1", Episodes: []*Episode{{ID: "ep2"}, {ID: "ep3"}}})
+	assert.True(t, archive.dirty)
+	assert.Nil(t, archive.Save())
+
+	// ep1 last seen at the first merge expires
+	now = time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)
+	series := &Series{Key: "test_1", Episodes: []*Episode{{ID: "ep2"}, {ID: "ep3"}}}
+	archive.merge(series)
+	assert.Nil(t, archive.Save())
+	assert.Equal(t, 3, len(series.Episodes))
+
+	now = now.Add(time.Hour)
+	series = &Series{Key: "test_1", Episodes: []*Episode{{ID: "ep2"}, {ID: "ep3"}}}
+	archive.merge(series)
+	assert.True(t, archive.dirty)
+	assert.Equal(t, 2, len(series.Episodes))
+}
+
+func TestRegistryArchive(t *testing.T) {
+	episodes := []*Episode{{ID: "ep1"}, {ID: "ep2"}}
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", "https://example.com/", func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		return &Series{Key: "test_1", Episodes: episodes}, HttpMetadata{}, nil
+	})))
+
+	archive, err := OpenArchive(filepath.Join(t.TempDir(), "archive.json"), ArchiveRetention{})
+	assert.Nil(t, err)
+	ctx := SetArchive(context.Background(), archive)
+
+	_, _, err = r.GetSeries(ctx, "https://example.com/1")
+	assert.Nil(t, err)
+
+	episodes = []*Episode{{ID: "ep2"}}
+	series, _, err := r.GetSeries(ctx, "https://example.com/1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(series.Episodes))
+	assert.True(t, series.Episodes[1].Unavailable)
+}
@@ -0,0 +1,96 @@
+package siteloader
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+// FeedResult is the result of a target of GetFeeds.
+type FeedResult struct {
+	Target string
+	// Key is the file name(without extension) of the feed.
+	Key string
+	// Series is rendered to the feed by Render in each format.
+	Series   *Series
+	Metadata HttpMetadata
+	Err      error
+}
+
+// GetFeeds loads the series of the feeds of targets with DefaultRegistry. See Registry.GetFeeds.
+func GetFeeds(ctx context.Context, targets []string, parallel int, opts ...Option) <-chan FeedResult {
+	return DefaultRegistry.GetFeeds(ctx, targets, parallel, opts...)
+}
+
+// GetFeeds loads the series of the feeds of targets with at most parallel workers and opts.
+// The results are sent in the order of completion and the channel is closed
+// when all targets are done. Targets not started before ctx is done result in ctx.Err().
+// Per-host limits of the Client in ctx are shared by the workers.
+func (r *Registry) GetFeeds(ctx context.Context, targets []string, parallel int, opts ...Option) <-chan FeedResult {
+	parallel = max(1, min(parallel, len(targets)))
+
+	// buffered not to leak workers even if the caller stops receiving.
+	results := make(chan FeedResult, len(targets))
+	jobs := make(chan string)
+
+	var wg sync.WaitGroup
+	for range parallel {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range jobs {
+				if err := ctx.Err(); err != nil {
+					results <- FeedResult{Target: target, Err: err}
+					continue
+				}
+				series, metadata, err := r.GetSeriesWithOptions(ctx, target, opts...)
+				if err != nil {
+					results <- FeedResult{Target: target, Metadata: metadata, Err: err}
+					continue
+				}
+				results <- FeedResult{Target: target, Key: series.Key, Series: series, Metadata: metadata}
+			}
+		}()
+	}
+
+	go func() {
+		for _, target := range interleaveHosts(targets) {
+			jobs <- target
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// interleaveHosts reorders targets to take each host in turn, so that workers
+// are not blocked by the per-host limit while requests to other hosts can be sent.
+// The order of targets of the same host is kept.
+func interleaveHosts(targets []string) []string {
+	var hosts []string
+	byHost := make(map[string][]string)
+	for _, target := range targets {
+		host := ""
+		if u, err := url.Parse(target); err == nil {
+			host = u.Host
+		}
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], target)
+	}
+
+	ordered := make([]string, 0, len(targets))
+	for len(ordered) < len(targets) {
+		for _, host := range hosts {
+			if len(byHost[host]) == 0 {
+				continue
+			}
+			ordered = append(ordered, byHost[host][0])
+			byHost[host] = byHost[host][1:]
+		}
+	}
+	return ordered
+}
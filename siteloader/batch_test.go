@@ -0,0 +1,96 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterleaveHosts(t *testing.T) {
+	assert.Equal(t, []string{
+		"https://a.example.com/1",
+		"https://b.example.com/1",
+		"hoge",
+		"https://a.example.com/2",
+		"https://b.example.com/2",
+		"https://a.example.com/3",
+	}, interleaveHosts([]string{
+		"https://a.example.com/1",
+		"https://a.example.com/2",
+		"https://a.example.com/3",
+		"https://b.example.com/1",
+		"hoge",
+		"https://b.example.com/2",
+	}))
+	assert.Equal(t, []string{}, interleaveHosts(nil))
+}
+
+func TestGetFeeds(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", "https://example.com/", func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		if target.Path == "/broken" {
+			return nil, HttpMetadata{}, &LayoutError{Site: "test", Selector: "h2"}
+		}
+		return &Series{Key: "test" + escapePath(target.Path), Episodes: []*Episode{{ID: "ep1"}}}, HttpMetadata{}, nil
+	})))
+
+	targets := []string{
+		"https://example.com/1",
+		"https://example.com/2",
+		"https://example.com/broken",
+		"https://example.com/3",
+		"https://example.com/4",
+		"https://www.example.com/",
+	}
+
+	results := map[string]FeedResult{}
+	for result := range r.GetFeeds(context.Background(), targets, 2) {
+		results[result.Target] = result
+	}
+
+	assert.Equal(t, len(targets), len(results))
+	assert.Equal(t, int32(2), maxRunning.Load())
+
+	assert.Nil(t, results["https://example.com/1"].Err)
+	assert.Equal(t, "test1", results["https://example.com/1"].Key)
+	assert.Equal(t, "test1", results["https://example.com/1"].Series.Key)
+	assert.Equal(t, 1, len(results["https://example.com/1"].Series.Episodes))
+
+	var layout *LayoutError
+	assert.True(t, errors.As(results["https://example.com/broken"].Err, &layout))
+	var unsupported *UnsupportedSiteError
+	assert.True(t, errors.As(results["https://www.example.com/"].Err, &unsupported))
+}
+
+func TestGetFeedsCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", "https://example.com/", func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		cancel()
+		return &Series{Key: "test"}, HttpMetadata{}, nil
+	})))
+
+	canceled := 0
+	for result := range r.GetFeeds(ctx, []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"}, 1) {
+		if errors.Is(result.Err, context.Canceled) {
+			canceled++
+		}
+	}
+	assert.Equal(t, 2, canceled)
+}
@@ -0,0 +1,141 @@
+package siteloader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/saintfish/chardet"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// CharsetSource tells which source decided the charset of the document.
+type CharsetSource string
+
+const (
+	// CharsetFromBOM means the byte order mark of the body.
+	CharsetFromBOM CharsetSource = "bom"
+	// CharsetFromHeader means the charset parameter of the Content-Type header.
+	CharsetFromHeader CharsetSource = "header"
+	// CharsetFromMeta means <meta charset> or <meta http-equiv="Content-Type">.
+	CharsetFromMeta CharsetSource = "meta"
+	// CharsetDetected means the guess of chardet.
+	CharsetDetected CharsetSource = "chardet"
+)
+
+// length of the body to look for <meta> charset declaration.
+const metaPrescanSize = 4096
+
+var boms = []struct {
+	bom     []byte
+	charset string
+}{
+	{[]byte{0xef, 0xbb, 0xbf}, "utf-8"},
+	{[]byte{0xfe, 0xff}, "utf-16be"},
+	{[]byte{0xff, 0xfe}, "utf-16le"},
+}
+
+// resolveCharset returns the charset of body and its source.
+// BOM, Content-Type header and <meta> are preferred, chardet is the last resort.
+func resolveCharset(body []byte, contentType string) (string, CharsetSource, error) {
+	for _, b := range boms {
+		if bytes.HasPrefix(body, b.bom) {
+			return b.charset, CharsetFromBOM, nil
+		}
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if cs, ok := knownCharset(params["charset"]); ok {
+			return cs, CharsetFromHeader, nil
+		}
+	}
+
+	if cs, ok := knownCharset(metaCharset(body)); ok {
+		return cs, CharsetFromMeta, nil
+	}
+
+	detector := chardet.NewTextDetector()
+	result, err := detector.DetectBest(body)
+	if err != nil {
+		return "", "", fmt.Errorf("charset detect error:%w", err)
+	}
+	return result.Charset, CharsetDetected, nil
+}
+
+// knownCharset returns the canonical name of label if it is a supported charset.
+func knownCharset(label string) (string, bool) {
+	if label == "" {
+		return "", false
+	}
+	e, name := charset.Lookup(label)
+	if e == nil {
+		return "", false
+	}
+	return name, true
+}
+
+// metaCharset returns the charset declared by <meta> in the beginning of body.
+func metaCharset(body []byte) string {
+	if len(body) > metaPrescanSize {
+		body = body[:metaPrescanSize]
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "meta":
+			case "body":
+				return ""
+			default:
+				continue
+			}
+
+			var cs, httpEquiv, content string
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "charset":
+					cs = strings.TrimSpace(string(val))
+				case "http-equiv":
+					httpEquiv = string(val)
+				case "content":
+					content = string(val)
+				}
+			}
+
+			if cs != "" {
+				return cs
+			}
+			if strings.EqualFold(httpEquiv, "content-type") {
+				if _, params, err := mime.ParseMediaType(content); err == nil && params["charset"] != "" {
+					return params["charset"]
+				}
+			}
+		}
+	}
+}
+
+// newCharsetReader converts body in the resolved charset to UTF-8.
+func newCharsetReader(body []byte, metadata *HttpMetadata) (io.Reader, error) {
+	cs, source, err := resolveCharset(body, metadata.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Charset = cs
+	metadata.CharsetSource = source
+
+	reader, err := charset.NewReaderLabel(cs, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("charset convert error:%w", err)
+	}
+	return reader, nil
+}
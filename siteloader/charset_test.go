@@ -0,0 +1,66 @@
+package siteloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// "テスト" in Shift_JIS
+const sjisTest = "\x83\x65\x83\x58\x83\x67"
+
+func TestResolveCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+		source      CharsetSource
+	}{
+		{name: "bom", body: "\xef\xbb\xbf<title>a</title>", contentType: "text/html; charset=Shift_JIS", want: "utf-8", source: CharsetFromBOM},
+		{name: "header", body: `<meta charset="EUC-JP"><title>` + sjisTest + `</title>`, contentType: "text/html; charset=Shift_JIS", want: "shift_jis", source: CharsetFromHeader},
+		{name: "header alias", body: "", contentType: "text/html; charset=x-sjis", want: "shift_jis", source: CharsetFromHeader},
+		{name: "meta charset", body: `<html><head><meta charset="Shift_JIS"><title>` + sjisTest + `</title>`, contentType: "text/html", want: "shift_jis", source: CharsetFromMeta},
+		{name: "meta http-equiv", body: `<html><head><META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=euc-jp"></head>`, contentType: "", want: "euc-jp", source: CharsetFromMeta},
+		{name: "unknown header", body: `<meta charset="Shift_JIS">`, contentType: "text/html; charset=saitama", want: "shift_jis", source: CharsetFromMeta},
+		{name: "meta in body", body: `<html><body><meta charset="Shift_JIS">example</body>`, contentType: "text/html", want: "ISO-8859-1", source: CharsetDetected},
+		{name: "chardet", body: "example", contentType: "", want: "ISO-8859-1", source: CharsetDetected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, source, err := resolveCharset([]byte(tt.body), tt.contentType)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.source, source)
+		})
+	}
+}
+
+func TestFetchDocumentCharset(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/header" {
+			w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+		} else {
+			w.Header().Set("Content-Type", "text/html")
+		}
+		// too short for chardet to guess
+		w.Write([]byte("<title>" + sjisTest + "</title>"))
+	}))
+	defer testsv.Close()
+
+	testUrl, _ := url.Parse(testsv.URL + "/header")
+	doc, metadata, err := fetchDocument(context.Background(), testUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, "テスト", doc.Find("title").Text())
+	assert.Equal(t, "shift_jis", metadata.Charset)
+	assert.Equal(t, CharsetFromHeader, metadata.CharsetSource)
+
+	testUrl, _ = url.Parse(testsv.URL + "/none")
+	_, metadata, err = fetchDocument(context.Background(), testUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, CharsetDetected, metadata.CharsetSource)
+}
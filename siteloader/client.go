@@ -0,0 +1,119 @@
+package siteloader
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Doer sends an HTTP request. *http.Client satisfies this interface.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client is the HTTP client shared by all loaders.
+type Client struct {
+	// HTTP sends requests. http.DefaultClient is used if nil.
+	HTTP Doer
+	// UserAgent is set to every request if not empty.
+	UserAgent string
+	// Retry configures retries of failed requests.
+	Retry RetryPolicy
+	// Limiter limits requests per host, including retries. no limit if nil.
+	Limiter *HostLimiter
+	// Robots makes requests comply with robots.txt. ignored if nil.
+	Robots *RobotsPolicy
+}
+
+// DefaultClient is used when no client is set to the context.
+var DefaultClient = &Client{UserAgent: "Saitama"}
+
+// Do sends req with the underlying Doer, retrying as configured.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	doer := c.HTTP
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+
+	if c.Limiter != nil {
+		doer = &limitedDoer{limiter: c.Limiter, doer: doer}
+	}
+
+	if c.Robots != nil {
+		if err := c.Robots.check(req.Context(), doer, req.URL, c.UserAgent); err != nil {
+			return nil, err
+		}
+		doer = &limitedDoer{limiter: c.Robots.delay, doer: doer}
+	}
+
+	started := time.Now()
+	logger := getLogger(req.Context())
+	hooks := getHooks(req.Context())
+	site := getSite(req.Context())
+	url := req.URL.String()
+
+	hooks.fetchStart(FetchStartEvent{Site: site, URL: url})
+
+	res, err := c.Retry.doWithRetry(doer, req)
+	if err != nil {
+		duration := time.Since(started)
+		logger.Debug("fetch failed",
+			"url", url,
+			"duration", duration,
+			"error", err,
+		)
+		hooks.fetchEnd(FetchEndEvent{Site: site, URL: url, Duration: duration, Err: err})
+		return nil, err
+	}
+
+	status := res.StatusCode
+	res.Body = &countedBody{
+		ReadCloser: res.Body,
+		onClose: func(bytes int64) {
+			duration := time.Since(started)
+			logger.Debug("fetched",
+				"url", url,
+				"status", status,
+				"bytes", bytes,
+				"duration", duration,
+			)
+			hooks.fetchEnd(FetchEndEvent{Site: site, URL: url, Status: status, Bytes: bytes, Duration: duration})
+		},
+	}
+	return res, nil
+}
+
+type limitedDoer struct {
+	limiter *HostLimiter
+	doer    Doer
+}
+
+func (d *limitedDoer) Do(req *http.Request) (*http.Response, error) {
+	if err := d.limiter.Wait(req.Context(), req.URL.Host); err != nil {
+		return nil, err
+	}
+	return d.doer.Do(req)
+}
+
+const clientKey = clientType("Client")
+
+type clientType string
+
+// SetClient returns the context which makes loaders use c.
+func SetClient(ctx context.Context, c *Client) context.Context {
+	if c == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, clientKey, c)
+}
+
+func getClient(ctx context.Context) *Client {
+	if c, ok := ctx.Value(clientKey).(*Client); ok {
+		return c
+	}
+	return DefaultClient
+}
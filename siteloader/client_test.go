@@ -0,0 +1,80 @@
+package siteloader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rewriteTransport sends every request to the test server regardless of its host.
+type rewriteTransport struct {
+	base *url.URL
+}
+
+func (rt *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = rt.base.Scheme
+	r.URL.Host = rt.base.Host
+	r.Host = ""
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newRewriteClient(t *testing.T, testsv *httptest.Server) *Client {
+	t.Helper()
+	base, err := url.Parse(testsv.URL)
+	if err != nil {
+		t.Fatalf("cannot parse test server URL:%v", err)
+	}
+	return &Client{
+		HTTP:      &http.Client{Transport: &rewriteTransport{base: base}},
+		UserAgent: "Saitama",
+	}
+}
+
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientUserAgent(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("User-Agent"))
+	}))
+	defer testsv.Close()
+
+	testUrl, _ := url.Parse(testsv.URL)
+
+	doc, _, err := fetchDocument(context.Background(), testUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, "Saitama", doc.Text())
+
+	ctx := SetClient(context.Background(), &Client{UserAgent: "Urawa"})
+	doc, _, err = fetchDocument(ctx, testUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, "Urawa", doc.Text())
+}
+
+func TestSetClient(t *testing.T) {
+	assert.Equal(t, DefaultClient, getClient(context.Background()))
+	assert.Equal(t, DefaultClient, getClient(SetClient(context.Background(), nil)))
+
+	called := false
+	c := &Client{HTTP: doerFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return nil, fmt.Errorf("test error")
+	})}
+
+	ctx := SetClient(context.Background(), c)
+	assert.Equal(t, c, getClient(ctx))
+
+	testUrl, _ := url.Parse("https://www.example.com/")
+	_, _, err := fetchDocument(ctx, testUrl)
+	assert.Error(t, err)
+	assert.True(t, called)
+}
@@ -8,8 +8,6 @@ import (
 	"net/url"
 	"strings"
 	"time"
-
-	"github.com/gorilla/feeds"
 )
 
 func sanitizeComicWalkerURL(target *url.URL) (*url.URL, error) {
@@ -22,23 +20,24 @@ func sanitizeComicWalkerURL(target *url.URL) (*url.URL, error) {
 	return target, nil
 }
 
-func comicwalkerFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMetadata, error) {
+func comicwalkerFeed(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
 
-	target, err := sanitizeComicWalkerURL(target)
+	sanitized, err := sanitizeComicWalkerURL(target)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("comicwalker:URLSanitizeErr:%w", err)
+		return nil, HttpMetadata{}, &UnsupportedSiteError{URL: target.String(), Err: err}
 	}
+	target = sanitized
 
-	fmt.Printf("comicwalker: target=%s\n", target.String())
+	getLogger(ctx).Debug("comicwalker sanitized", "url", target.String())
 
 	doc, metadata, err := fetchDocument(ctx, target)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("comicwalker:FetchErr:%w", err)
+		return nil, metadata, fmt.Errorf("comicwalker:FetchErr:%w", err)
 	}
 
 	script := doc.Find("script#__NEXT_DATA__").Text()
 	if script == "" {
-		return "", nil, metadata, errors.New("comicwalker:__NEXT_DATA__ not found")
+		return nil, metadata, &LayoutError{Site: "comicwalker", Selector: "script#__NEXT_DATA__"}
 	}
 
 	var walkerNextData struct {
@@ -53,16 +52,16 @@ func comicwalkerFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed,
 	}
 
 	if err := json.Unmarshal([]byte(script), &walkerNextData); err != nil {
-		return "", nil, metadata, fmt.Errorf("comicwalker:__NEXT_DATA__ parse error %w", err)
+		return nil, metadata, &LayoutError{Site: "comicwalker", Selector: "script#__NEXT_DATA__", Err: err}
 	}
 
 	if len(walkerNextData.Props.PageProps.DehydratedState.Queries) == 0 {
-		return "", nil, metadata, errors.New("comicwalker:Queries not found")
+		return nil, metadata, &LayoutError{Site: "comicwalker", Selector: "dehydratedState.queries"}
 	}
 
 	detailJSON, err := getComicDetailJSON(walkerNextData.Props.PageProps.DehydratedState.Queries)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("comicwalker:DetailErr:%w", err)
+		return nil, metadata, &LayoutError{Site: "comicwalker", Selector: "dehydratedState.queries(/api/contents/details/work)", Err: err}
 	}
 
 	var comicDetail struct {
@@ -103,7 +102,7 @@ func comicwalkerFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed,
 	}
 
 	if err := json.Unmarshal(detailJSON, &comicDetail); err != nil {
-		return "", nil, metadata, fmt.Errorf("comicwalker:Detailed JSON parse error %w", err)
+		return nil, metadata, &LayoutError{Site: "comicwalker", Selector: "dehydratedState.queries(/api/contents/details/work).state", Err: err}
 	}
 
 	authors := make([]string, 0, len(comicDetail.Data.Work.Authors))
@@ -111,28 +110,36 @@ func comicwalkerFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed,
 		authors = append(authors, fmt.Sprintf("%s(%s)", a.Name, a.Role))
 	}
 
-	feed := &feeds.Feed{
+	series := &Series{
+		Site:        "comicwalker",
+		Key:         "comicwalker_" + walkerNextData.Props.PageProps.WorkCode,
 		Title:       comicDetail.Data.Work.Title,
-		Link:        &feeds.Link{Href: fmt.Sprintf("https://comic-walker.com/detail/%s", walkerNextData.Props.PageProps.WorkCode)},
+		Link:        fmt.Sprintf("https://comic-walker.com/detail/%s", walkerNextData.Props.PageProps.WorkCode),
 		Description: trimDescription(comicDetail.Data.Work.Summary),
-		Author:      &feeds.Author{Name: strings.Join(authors, ", ")},
+		Author:      strings.Join(authors, ", "),
 	}
 
 	for _, ep := range comicDetail.Data.FirstEpisodes.Result {
 		if !ep.IsActive {
 			continue
 		}
-		feed.Items = append(feed.Items, &feeds.Item{
+		series.Episodes = append(series.Episodes, &Episode{
+			ID:          ep.ID,
 			Title:       ep.Title,
-			Link:        &feeds.Link{Href: fmt.Sprintf("https://comic-walker.com/detail/%s/episodes/%s", walkerNextData.Props.PageProps.WorkCode, ep.Code)},
-			Id:          ep.ID,
-			Created:     ep.UpdateDate,
+			Link:        fmt.Sprintf("https://comic-walker.com/detail/%s/episodes/%s", walkerNextData.Props.PageProps.WorkCode, ep.Code),
 			Description: ep.SubTitle,
+			Number:      ep.Internal.EpisodeNo,
+			Thumbnail:   ep.Thumbnail,
+			Created:     ep.UpdateDate,
 		})
-		feed.Updated = ep.UpdateDate
+		series.Updated = ep.UpdateDate
+	}
+
+	if len(series.Episodes) == 0 {
+		return nil, metadata, &NoEpisodesError{Site: "comicwalker", URL: target.String()}
 	}
 
-	return "comicwalker_" + walkerNextData.Props.PageProps.WorkCode, feed, metadata, nil
+	return series, metadata, nil
 }
 
 func getComicDetailJSON(data []map[string]interface{}) ([]byte, error) {
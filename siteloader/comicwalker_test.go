@@ -25,10 +25,11 @@ func TestComicWalker(t *testing.T) {
 	defer testsv.Close()
 
 	testUrl, _ := url.Parse(testsv.URL + "/detail/KC_WCODE_SAMPLE")
-	fname, feed, _, err := comicwalkerFeed(context.Background(), testUrl)
+	series, _, err := comicwalkerFeed(context.Background(), testUrl)
 	assert.Nil(t, err)
+	feed := series.Feed()
 
-	assert.Equal(t, "comicwalker_KC_WCODE_SAMPLE", fname)
+	assert.Equal(t, "comicwalker_KC_WCODE_SAMPLE", series.Key)
 
 	assert.Equal(t, "テストタイトル", feed.Title)
 	assert.Equal(t, "https://comic-walker.com/detail/KC_WCODE_SAMPLE", feed.Link.Href)
@@ -61,7 +62,7 @@ func TestComicWalker(t *testing.T) {
 
 	for index, tt := range testcases {
 		t.Run(tt.title, func(t *testing.T) {
-			assert.Equal(t, tt.id, feed.Items[index].Id)
+			assert.Equal(t, tt.id, series.Episodes[index].ID)
 			assert.Equal(t, tt.path, feed.Items[index].Link.Href)
 			assert.Equal(t, tt.title, feed.Items[index].Title)
 
@@ -0,0 +1,104 @@
+package siteloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// setConditionalHeaders sets If-None-Match and If-Modified-Since in ctx to req.
+func setConditionalHeaders(ctx context.Context, req *http.Request) {
+	if ifNoneMatch, ok := getIfNoneMatch(ctx); ok {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	if ifModifiedSince, ok := getIsModifiedSince(ctx); ok {
+		req.Header.Set("If-Modified-Since", ifModifiedSince)
+	}
+}
+
+// hashETag returns the weak ETag computed from key and the response bodies.
+// it is used when the upstream API has no validators.
+func hashETag(key string, bodies ...[]byte) string {
+	h := sha256.New()
+	h.Write([]byte(key))
+	for _, b := range bodies {
+		h.Write([]byte{0})
+		h.Write(b)
+	}
+	return fmt.Sprintf(`W/"%x"`, h.Sum(nil)[:16])
+}
+
+// fillValidators sets the computed validators to metadata if the upstream does not give them.
+func fillValidators(metadata *HttpMetadata, etag string, updated time.Time) {
+	if metadata.ETag == "" {
+		metadata.ETag = etag
+	}
+	if metadata.LastModified == "" && !updated.IsZero() {
+		metadata.LastModified = updated.UTC().Format(http.TimeFormat)
+	}
+}
+
+// contentValidators returns the weak ETag derived from the content of series
+// and the latest time in it. they are stable while the feed is unchanged.
+func contentValidators(series *Series) (string, time.Time, error) {
+	content, err := json.Marshal(series)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("cannot marshal series:%w", err)
+	}
+
+	return hashETag(series.Key, content), series.latestTime(), nil
+}
+
+// checkNotModified returns ErrNotModified if metadata satisfies
+// the conditional request in ctx (RFC 9110 13.2.2).
+func checkNotModified(ctx context.Context, metadata HttpMetadata) error {
+	if ifNoneMatch, ok := getIfNoneMatch(ctx); ok {
+		if matchETag(ifNoneMatch, metadata.ETag) {
+			return ErrNotModified
+		}
+		// If-Modified-Since is ignored when If-None-Match exists.
+		return nil
+	}
+
+	ifModifiedSince, ok := getIsModifiedSince(ctx)
+	if !ok || metadata.LastModified == "" {
+		return nil
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return nil
+	}
+	modified, err := http.ParseTime(metadata.LastModified)
+	if err != nil {
+		return nil
+	}
+	if !modified.After(since) {
+		return ErrNotModified
+	}
+	return nil
+}
+
+// matchETag reports whether etag matches If-None-Match header with weak comparison.
+func matchETag(ifNoneMatch, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutConditional returns ctx without If-None-Match and If-Modified-Since.
+func withoutConditional(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, ifNoneMatchKey, nil)
+	return context.WithValue(ctx, ifModifiedSinceKey, nil)
+}
@@ -0,0 +1,178 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchETag(t *testing.T) {
+	assert.True(t, matchETag(`"abc"`, `"abc"`))
+	assert.True(t, matchETag(`W/"abc"`, `"abc"`))
+	assert.True(t, matchETag(`"xyz", W/"abc"`, `W/"abc"`))
+	assert.True(t, matchETag(`*`, `"abc"`))
+	assert.False(t, matchETag(`"xyz"`, `"abc"`))
+	assert.False(t, matchETag(`"abc"`, ``))
+}
+
+func TestCheckNotModified(t *testing.T) {
+	metadata := HttpMetadata{ETag: `W/"abc"`, LastModified: "Sun, 30 Jun 2024 00:00:00 GMT"}
+
+	assert.Nil(t, checkNotModified(context.Background(), metadata))
+
+	ctx := SetIfNoneMatch(context.Background(), `W/"abc"`)
+	assert.True(t, errors.Is(checkNotModified(ctx, metadata), ErrNotModified))
+
+	// If-Modified-Since is ignored when If-None-Match exists
+	ctx = SetIfModifiedSince(SetIfNoneMatch(context.Background(), `"xyz"`), "Mon, 01 Jul 2024 00:00:00 GMT")
+	assert.Nil(t, checkNotModified(ctx, metadata))
+
+	ctx = SetIfModifiedSince(context.Background(), "Sun, 30 Jun 2024 00:00:00 GMT")
+	assert.True(t, errors.Is(checkNotModified(ctx, metadata), ErrNotModified))
+
+	ctx = SetIfModifiedSince(context.Background(), "Sat, 29 Jun 2024 00:00:00 GMT")
+	assert.Nil(t, checkNotModified(ctx, metadata))
+
+	// conditional values are removed
+	ctx = withoutConditional(SetIfNoneMatch(context.Background(), `W/"abc"`))
+	assert.Nil(t, checkNotModified(ctx, metadata))
+}
+
+func TestFillValidators(t *testing.T) {
+	updated := time.Date(2024, 6, 30, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+
+	var metadata HttpMetadata
+	fillValidators(&metadata, hashETag("key", []byte("body")), updated)
+	assert.Equal(t, hashETag("key", []byte("body")), metadata.ETag)
+	assert.Equal(t, "Sun, 30 Jun 2024 00:00:00 GMT", metadata.LastModified)
+	assert.NotEqual(t, hashETag("key2", []byte("body")), metadata.ETag)
+
+	// upstream validators are preferred
+	metadata = HttpMetadata{ETag: `"upstream"`}
+	fillValidators(&metadata, hashETag("key", []byte("body")), updated)
+	assert.Equal(t, HttpMetadata{ETag: `"upstream"`, LastModified: "Sun, 30 Jun 2024 00:00:00 GMT"}, metadata)
+
+	metadata = HttpMetadata{LastModified: "Mon, 01 Jul 2024 00:00:00 GMT"}
+	fillValidators(&metadata, hashETag("key", []byte("body")), time.Time{})
+	assert.Equal(t, HttpMetadata{ETag: hashETag("key", []byte("body")), LastModified: "Mon, 01 Jul 2024 00:00:00 GMT"}, metadata)
+}
+
+func TestContentValidators(t *testing.T) {
+	created := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	newSeries := func() *Series {
+		return &Series{
+			Key:   "test",
+			Title: "title",
+			Episodes: []*Episode{
+				{ID: "1", Title: "ep1", Created: created},
+				{ID: "2", Title: "ep2", Updated: updated},
+			},
+		}
+	}
+
+	etag, latest, err := contentValidators(newSeries())
+	assert.Nil(t, err)
+	assert.Equal(t, updated, latest)
+
+	again, _, err := contentValidators(newSeries())
+	assert.Nil(t, err)
+	assert.Equal(t, etag, again)
+
+	changed := newSeries()
+	changed.Episodes = append(changed.Episodes, &Episode{ID: "3", Title: "ep3"})
+	other, _, err := contentValidators(changed)
+	assert.Nil(t, err)
+	assert.NotEqual(t, etag, other)
+}
+
+func TestRegistryConditional(t *testing.T) {
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", "https://example.com/", func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		return &Series{
+			Key:      "test",
+			Episodes: []*Episode{{ID: "1", Updated: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)}},
+		}, HttpMetadata{}, nil
+	})))
+
+	_, metadata, err := r.GetSeries(context.Background(), "https://example.com/1")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, metadata.ETag)
+	assert.Equal(t, "Sun, 30 Jun 2024 00:00:00 GMT", metadata.LastModified)
+
+	_, notModified, err := r.GetSeries(SetIfNoneMatch(context.Background(), metadata.ETag), "https://example.com/1")
+	assert.True(t, errors.Is(err, ErrNotModified))
+	assert.Equal(t, metadata.ETag, notModified.ETag)
+
+	_, _, err = r.GetSeries(SetIfModifiedSince(context.Background(), metadata.LastModified), "https://example.com/1")
+	assert.True(t, errors.Is(err, ErrNotModified))
+
+	_, _, err = r.GetSeries(SetIfNoneMatch(context.Background(), `W/"other"`), "https://example.com/1")
+	assert.Nil(t, err)
+}
+
+func TestFuzConditional(t *testing.T) {
+	testsv := newFuzTestServer(t)
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	testUrl, _ := url.Parse("https://comic-fuz.com/manga/1234")
+	_, metadata, err := fuzFeed(ctx, testUrl)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, metadata.ETag)
+	assert.Equal(t, "Sat, 29 Jun 2024 15:00:00 GMT", metadata.LastModified)
+
+	testUrl, _ = url.Parse("https://comic-fuz.com/manga/1234")
+	_, _, err = fuzFeed(SetIfNoneMatch(ctx, metadata.ETag), testUrl)
+	assert.True(t, errors.Is(err, ErrNotModified))
+
+	testUrl, _ = url.Parse("https://comic-fuz.com/manga/1234?freeOnly")
+	_, freeOnly, err := fuzFeed(SetIfNoneMatch(ctx, metadata.ETag), testUrl)
+	assert.Nil(t, err)
+	assert.NotEqual(t, metadata.ETag, freeOnly.ETag)
+}
+
+func TestFuzUpstreamNotModified(t *testing.T) {
+	testsv := newFuzTestServer(t)
+	defer testsv.Close()
+
+	client := newRewriteClient(t, testsv)
+	ctx := SetClient(context.Background(), &Client{HTTP: doerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("If-None-Match") == `"upstream"` {
+			return &http.Response{StatusCode: http.StatusNotModified, Body: http.NoBody, Request: req}, nil
+		}
+		return client.Do(req)
+	})})
+
+	testUrl, _ := url.Parse("https://comic-fuz.com/manga/1234")
+	_, _, err := fuzFeed(SetIfNoneMatch(ctx, `"upstream"`), testUrl)
+	assert.True(t, errors.Is(err, ErrNotModified))
+}
+
+func TestTakecomiConditional(t *testing.T) {
+	testsv := newTakecomiTestServer(t)
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	testUrl, _ := url.Parse("https://takecomic.jp/series/abcdef")
+	_, metadata, err := takecomiFeed(ctx, testUrl)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, metadata.ETag)
+	assert.NotEmpty(t, metadata.LastModified)
+
+	_, _, err = takecomiFeed(SetIfNoneMatch(ctx, metadata.ETag), testUrl)
+	assert.True(t, errors.Is(err, ErrNotModified))
+
+	_, _, err = takecomiFeed(SetIfModifiedSince(ctx, metadata.LastModified), testUrl)
+	assert.True(t, errors.Is(err, ErrNotModified))
+
+	_, _, err = takecomiFeed(SetIfNoneMatch(ctx, `W/"other"`), testUrl)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,466 @@
+package siteloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// SiteDefinitions is the format of the site definition file (YAML or JSON).
+type SiteDefinitions struct {
+	Sites []SiteDefinition `yaml:"sites" json:"sites"`
+}
+
+// SiteDefinition declares a site scraped with CSS selectors.
+// It is compiled into a Loader by Compile.
+type SiteDefinition struct {
+	// Name is the name of the site, also the prefix of the feed key.
+	// A definition named as a built-in site replaces it.
+	Name string `yaml:"name" json:"name"`
+	// Prefix is the URL prefix of the series handled.
+	Prefix string `yaml:"prefix" json:"prefix"`
+	// Pattern is the regular expression of the URL of the series handled,
+	// used instead of Prefix.
+	Pattern string `yaml:"pattern" json:"pattern"`
+
+	Title       Extraction `yaml:"title" json:"title"`
+	Author      Extraction `yaml:"author" json:"author"`
+	Description Extraction `yaml:"description" json:"description"`
+
+	Episodes EpisodeDefinition `yaml:"episodes" json:"episodes"`
+
+	// Next selects the link to the next page of the episode list. (attr defaults to href)
+	Next Extraction `yaml:"next" json:"next"`
+	// MaxPages limits the pages followed by Next. (default 10)
+	MaxPages int `yaml:"max_pages" json:"max_pages"`
+
+	// DateFormats are the layouts of time.Parse tried in order.
+	DateFormats []string `yaml:"date_formats" json:"date_formats"`
+	// Timezone of dates published without it. (default Asia/Tokyo)
+	Timezone string `yaml:"timezone" json:"timezone"`
+}
+
+// EpisodeDefinition declares the episode list. Extractions are relative to each element.
+type EpisodeDefinition struct {
+	// Selector selects each episode.
+	Selector string `yaml:"selector" json:"selector"`
+
+	Title Extraction `yaml:"title" json:"title"`
+	// Link is resolved from the page URL. (attr defaults to href)
+	Link Extraction `yaml:"link" json:"link"`
+	// Thumbnail is resolved from the page URL. (attr defaults to src)
+	Thumbnail Extraction `yaml:"thumbnail" json:"thumbnail"`
+	// Created and Updated are parsed with DateFormats of the site.
+	Created Extraction `yaml:"created" json:"created"`
+	Updated Extraction `yaml:"updated" json:"updated"`
+}
+
+// Extraction extracts a string from the selected element.
+type Extraction struct {
+	// Selector selects the element. the current element if empty.
+	Selector string `yaml:"selector" json:"selector"`
+	// Attr is the attribute extracted. the text if empty.
+	Attr string `yaml:"attr" json:"attr"`
+	// Regexp extracts its first submatch (or the match if no group) from the string.
+	Regexp string `yaml:"regexp" json:"regexp"`
+	// Optional makes the empty string allowed instead of LayoutError.
+	Optional bool `yaml:"optional" json:"optional"`
+}
+
+// ParseSiteDefinitions parses site definitions in YAML or JSON.
+// Unknown fields are errors to find typos.
+func ParseSiteDefinitions(data []byte) ([]SiteDefinition, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var defs SiteDefinitions
+	if err := decoder.Decode(&defs); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cannot parse site definitions: %w", err)
+	}
+	return defs.Sites, nil
+}
+
+// LoadSiteDefinitions reads site definitions from path and compiles them.
+func LoadSiteDefinitions(path string) ([]Loader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read site definitions: %w", err)
+	}
+
+	defs, err := ParseSiteDefinitions(data)
+	if err != nil {
+		return nil, err
+	}
+
+	loaders := make([]Loader, 0, len(defs))
+	for _, def := range defs {
+		l, err := def.Compile()
+		if err != nil {
+			return nil, err
+		}
+		loaders = append(loaders, l)
+	}
+	return loaders, nil
+}
+
+type compiledExtraction struct {
+	Extraction
+	configured bool
+	selector   cascadia.Selector
+	regexp     *regexp.Regexp
+	// name is used in errors.
+	name string
+}
+
+// layoutError returns LayoutError of the extraction.
+func (e *compiledExtraction) layoutError(site string, err error) error {
+	selector := e.Selector
+	if selector == "" {
+		selector = e.name
+	}
+	return &LayoutError{Site: site, Selector: selector, Err: err}
+}
+
+type definitionLoader struct {
+	def      SiteDefinition
+	pattern  *regexp.Regexp
+	location *time.Location
+
+	title, author, description   *compiledExtraction
+	episodes                     cascadia.Selector
+	epTitle, epLink, epThumbnail *compiledExtraction
+	epCreated, epUpdated, next   *compiledExtraction
+}
+
+// Compile validates d and returns the Loader.
+func (d SiteDefinition) Compile() (Loader, error) {
+	if d.Name == "" {
+		return nil, errors.New("site definition without name")
+	}
+	fail := func(format string, args ...any) (Loader, error) {
+		return nil, fmt.Errorf("site %q: %s", d.Name, fmt.Sprintf(format, args...))
+	}
+
+	l := &definitionLoader{def: d}
+	switch {
+	case d.Prefix != "" && d.Pattern != "":
+		return fail("both prefix and pattern are set")
+	case d.Pattern != "":
+		pattern, err := regexp.Compile(d.Pattern)
+		if err != nil {
+			return fail("invalid pattern: %v", err)
+		}
+		l.pattern = pattern
+	case d.Prefix == "":
+		return fail("prefix or pattern is required")
+	}
+
+	if d.Title.Selector == "" {
+		return fail("title.selector is required")
+	}
+	if d.Episodes.Selector == "" {
+		return fail("episodes.selector is required")
+	}
+	if d.MaxPages < 0 {
+		return fail("max_pages must not be negative")
+	}
+	if l.def.MaxPages == 0 {
+		l.def.MaxPages = 10
+	}
+
+	if d.Timezone != "" {
+		loc, err := time.LoadLocation(d.Timezone)
+		if err != nil {
+			return fail("invalid timezone: %v", err)
+		}
+		l.location = loc
+	}
+
+	episodes, err := cascadia.Compile(d.Episodes.Selector)
+	if err != nil {
+		return fail("invalid episodes.selector: %v", err)
+	}
+	l.episodes = episodes
+
+	compile := func(name string, e Extraction, defaultAttr string) (*compiledExtraction, error) {
+		c := &compiledExtraction{Extraction: e, configured: e != Extraction{}, name: name}
+		if c.Attr == "" {
+			c.Attr = defaultAttr
+		}
+		if e.Selector != "" {
+			sel, err := cascadia.Compile(e.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("site %q: invalid %s.selector: %w", d.Name, name, err)
+			}
+			c.selector = sel
+		}
+		if e.Regexp != "" {
+			re, err := regexp.Compile(e.Regexp)
+			if err != nil {
+				return nil, fmt.Errorf("site %q: invalid %s.regexp: %w", d.Name, name, err)
+			}
+			c.regexp = re
+		}
+		return c, nil
+	}
+
+	for _, c := range []struct {
+		dst         **compiledExtraction
+		name        string
+		e           Extraction
+		defaultAttr string
+	}{
+		{&l.title, "title", d.Title, ""},
+		{&l.author, "author", d.Author, ""},
+		{&l.description, "description", d.Description, ""},
+		{&l.epTitle, "episodes.title", d.Episodes.Title, ""},
+		{&l.epLink, "episodes.link", d.Episodes.Link, "href"},
+		{&l.epThumbnail, "episodes.thumbnail", d.Episodes.Thumbnail, "src"},
+		{&l.epCreated, "episodes.created", d.Episodes.Created, ""},
+		{&l.epUpdated, "episodes.updated", d.Episodes.Updated, ""},
+		{&l.next, "next", d.Next, "href"},
+	} {
+		compiled, err := compile(c.name, c.e, c.defaultAttr)
+		if err != nil {
+			return nil, err
+		}
+		*c.dst = compiled
+	}
+
+	if (l.epCreated.configured || l.epUpdated.configured) && len(d.DateFormats) == 0 {
+		return fail("date_formats is required to parse dates")
+	}
+	if l.next.configured && d.Next.Selector == "" {
+		return fail("next.selector is required")
+	}
+
+	return l, nil
+}
+
+func (l *definitionLoader) Name() string {
+	return l.def.Name
+}
+
+func (l *definitionLoader) SiteOptions() []SiteOption {
+	return []SiteOption{SiteOptionLocation}
+}
+
+func (l *definitionLoader) Match(target *url.URL) bool {
+	if l.pattern != nil {
+		return l.pattern.MatchString(target.String())
+	}
+	return strings.HasPrefix(target.String(), l.def.Prefix)
+}
+
+// extract returns the string extracted by e from s.
+// empty string is returned if the element is not found.
+func (l *definitionLoader) extract(s *goquery.Selection, e *compiledExtraction) (string, error) {
+	if e.selector != nil {
+		s = s.FindMatcher(goquery.SingleMatcher(e.selector))
+	}
+	if s.Length() == 0 {
+		if e.Optional {
+			return "", nil
+		}
+		return "", e.layoutError(l.def.Name, nil)
+	}
+
+	var value string
+	if e.Attr != "" {
+		value = strings.TrimSpace(s.AttrOr(e.Attr, ""))
+	} else {
+		value = trimDescription(s.Text())
+	}
+
+	if e.regexp != nil {
+		m := e.regexp.FindStringSubmatch(value)
+		switch {
+		case m == nil:
+			value = ""
+		case len(m) > 1:
+			value = m[1]
+		default:
+			value = m[0]
+		}
+	}
+
+	if value == "" && !e.Optional {
+		return "", e.layoutError(l.def.Name, fmt.Errorf("%s is empty", e.name))
+	}
+	return value, nil
+}
+
+func (l *definitionLoader) parseDate(s *goquery.Selection, e *compiledExtraction, loc *time.Location) (time.Time, error) {
+	if !e.configured {
+		return time.Time{}, nil
+	}
+
+	value, err := l.extract(s, e)
+	if err != nil || value == "" {
+		return time.Time{}, err
+	}
+
+	for _, layout := range l.def.DateFormats {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, e.layoutError(l.def.Name, fmt.Errorf("cannot parse %s[%s]", e.name, value))
+}
+
+func (l *definitionLoader) Load(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+	name := l.def.Name
+
+	loc := l.location
+	if loc == nil || getCallOptions(ctx).location != nil {
+		var err error
+		loc, err = siteLocation(ctx)
+		if err != nil {
+			return nil, HttpMetadata{}, fmt.Errorf("%s:%w", name, err)
+		}
+	}
+
+	paginated := l.next.configured
+	if paginated {
+		// a 304 of the first page says nothing of the episodes on the pages
+		// followed by the next selector. the registry checks the validators
+		// computed from all the pages instead.
+		ctx = withoutConditional(ctx)
+	}
+
+	doc, metadata, err := fetchDocument(ctx, target)
+	if err != nil {
+		return nil, metadata, fmt.Errorf("%s:FetchErr:%w", name, err)
+	}
+
+	title, err := l.extract(doc.Selection, l.title)
+	if err != nil {
+		return nil, metadata, err
+	}
+	series := &Series{
+		Site:  name,
+		Key:   name + "_" + escapePath(target.Path),
+		Title: title,
+		Link:  target.String(),
+	}
+	if l.author.configured {
+		if series.Author, err = l.extract(doc.Selection, l.author); err != nil {
+			return nil, metadata, err
+		}
+	}
+	if l.description.configured {
+		if series.Description, err = l.extract(doc.Selection, l.description); err != nil {
+			return nil, metadata, err
+		}
+	}
+
+	page := target
+	visited := map[string]bool{page.String(): true}
+	for pages := 1; ; pages++ {
+		if err := l.collectEpisodes(doc, page, loc, series); err != nil {
+			return nil, metadata, err
+		}
+
+		if !paginated || pages >= l.def.MaxPages {
+			break
+		}
+		next, err := l.extract(doc.Selection, l.next)
+		if err != nil {
+			var layout *LayoutError
+			if errors.As(err, &layout) {
+				// the last page
+				break
+			}
+			return nil, metadata, err
+		}
+		nextURL, err := page.Parse(next)
+		if err != nil {
+			return nil, metadata, l.next.layoutError(name, err)
+		}
+		if visited[nextURL.String()] {
+			break
+		}
+		visited[nextURL.String()] = true
+
+		page = nextURL
+		doc, metadata, err = fetchDocument(ctx, page)
+		if err != nil {
+			return nil, metadata, fmt.Errorf("%s:Fetch(Next)Err:%w", name, err)
+		}
+	}
+
+	if len(series.Episodes) == 0 {
+		return nil, metadata, &NoEpisodesError{Site: name, URL: target.String()}
+	}
+
+	if paginated {
+		return series, HttpMetadata{}, nil
+	}
+	return series, metadata, nil
+}
+
+func (l *definitionLoader) collectEpisodes(doc *goquery.Document, page *url.URL, loc *time.Location, series *Series) error {
+	var err error
+	doc.FindMatcher(l.episodes).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		var ep *Episode
+		ep, err = l.episode(s, page, loc)
+		if err != nil {
+			return false
+		}
+		series.Episodes = append(series.Episodes, ep)
+		return true
+	})
+	return err
+}
+
+func (l *definitionLoader) episode(s *goquery.Selection, page *url.URL, loc *time.Location) (*Episode, error) {
+	link, err := l.extract(s, l.epLink)
+	if err != nil {
+		return nil, err
+	}
+	link, err = resolveRelativeURI(page, link)
+	if err != nil {
+		return nil, l.epLink.layoutError(l.def.Name, err)
+	}
+
+	ep := &Episode{
+		ID:   generateHashedHex(link),
+		Link: link,
+	}
+
+	if ep.Title, err = l.extract(s, l.epTitle); err != nil {
+		return nil, err
+	}
+
+	if l.epThumbnail.configured {
+		thumbnail, err := l.extract(s, l.epThumbnail)
+		if err != nil {
+			return nil, err
+		}
+		if thumbnail != "" {
+			if ep.Thumbnail, err = resolveRelativeURI(page, thumbnail); err != nil {
+				return nil, l.epThumbnail.layoutError(l.def.Name, err)
+			}
+		}
+	}
+
+	if ep.Created, err = l.parseDate(s, l.epCreated, loc); err != nil {
+		return nil, err
+	}
+	if ep.Updated, err = l.parseDate(s, l.epUpdated, loc); err != nil {
+		return nil, err
+	}
+
+	return ep, nil
+}
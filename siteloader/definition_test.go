@@ -0,0 +1,147 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiteDefinition(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "2":
+			http.ServeFile(w, r, "./testdata/definition_test_p2.html")
+		default:
+			http.ServeFile(w, r, "./testdata/definition_test_p1.html")
+		}
+	}))
+	defer testsv.Close()
+
+	loaders, err := LoadSiteDefinitions("./testdata/definition_test.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(loaders))
+
+	r := NewRegistry()
+	assert.Nil(t, r.Register(loaders[0]))
+
+	series, metadata, err := r.GetSeries(context.Background(), testsv.URL+"/series/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "example", series.Site)
+	assert.Equal(t, "example_series1", series.Key)
+	assert.Equal(t, "例の作品", series.Title)
+	assert.Equal(t, "埼玉太郎", series.Author)
+	assert.Equal(t, "あらすじ一行目あらすじ二行目", series.Description)
+	// computed from the content
+	assert.NotEmpty(t, metadata.ETag)
+
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	assert.Equal(t, 3, len(series.Episodes))
+
+	assert.Equal(t, "第1話", series.Episodes[0].Title)
+	assert.Equal(t, testsv.URL+"/episode/1", series.Episodes[0].Link)
+	assert.Equal(t, generateHashedHex(testsv.URL+"/episode/1"), series.Episodes[0].ID)
+	assert.Equal(t, testsv.URL+"/img/1.jpg", series.Episodes[0].Thumbnail)
+	assert.Equal(t, time.Date(2024, 6, 1, 12, 0, 0, 0, jst), series.Episodes[0].Created)
+	assert.True(t, series.Episodes[0].Updated.IsZero())
+
+	assert.Equal(t, testsv.URL+"/series/episode/2", series.Episodes[1].Link)
+	assert.Equal(t, "", series.Episodes[1].Thumbnail)
+	assert.Equal(t, time.Date(2024, 6, 8, 0, 0, 0, 0, jst), series.Episodes[1].Created)
+	assert.Equal(t, time.Date(2024, 6, 9, 9, 30, 0, 0, jst), series.Episodes[1].Updated)
+
+	assert.Equal(t, "第3話", series.Episodes[2].Title)
+	assert.Equal(t, "https://example.com/episode/3", series.Episodes[2].Link)
+
+	// timezone
+	series, _, err = r.GetSeriesWithOptions(context.Background(), testsv.URL+"/series/1", WithLocation(time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), series.Episodes[0].Created)
+
+	// not matched
+	_, _, err = r.GetSeries(context.Background(), testsv.URL+"/other/1")
+	var unsupported *UnsupportedSiteError
+	assert.True(t, errors.As(err, &unsupported))
+}
+
+func TestSiteDefinitionLayout(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<h1>title</h1><ul><li><a>no link</a></li></ul>`)
+	}))
+	defer testsv.Close()
+
+	l, err := SiteDefinition{
+		Name:     "example",
+		Prefix:   testsv.URL,
+		Title:    Extraction{Selector: "h1"},
+		Episodes: EpisodeDefinition{Selector: "li", Link: Extraction{Selector: "a"}},
+	}.Compile()
+	assert.Nil(t, err)
+
+	target, _ := url.Parse(testsv.URL)
+	_, _, err = l.Load(context.Background(), target)
+	var layout *LayoutError
+	assert.True(t, errors.As(err, &layout))
+	assert.Equal(t, "example", layout.Site)
+	assert.Equal(t, "a", layout.Selector)
+
+	l, err = SiteDefinition{
+		Name:     "example",
+		Prefix:   testsv.URL,
+		Title:    Extraction{Selector: "h2"},
+		Episodes: EpisodeDefinition{Selector: "li"},
+	}.Compile()
+	assert.Nil(t, err)
+	_, _, err = l.Load(context.Background(), target)
+	assert.True(t, errors.As(err, &layout))
+	assert.Equal(t, "h2", layout.Selector)
+}
+
+func TestSiteDefinitionInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		yaml string
+		err  string
+	}{
+		{"sites:\n  - name: a\n    titel: {selector: h1}\n", "field titel not found"},
+		{"sites:\n  - prefix: https://example.com/\n", "site definition without name"},
+		{"sites:\n  - name: a\n    title: {selector: h1}\n    episodes: {selector: li}\n", `site "a": prefix or pattern is required`},
+		{"sites:\n  - name: a\n    pattern: \"[\"\n", `site "a": invalid pattern`},
+		{"sites:\n  - name: a\n    prefix: https://example.com/\n    title: {selector: h1}\n    episodes: {selector: li}\n    next: {selector: \"a[\"}\n", `site "a": invalid next.selector`},
+		{"sites:\n  - name: a\n    prefix: https://example.com/\n    title: {selector: h1}\n    episodes: {selector: li, created: {selector: time}}\n", `site "a": date_formats is required`},
+	} {
+		defs, err := ParseSiteDefinitions([]byte(tc.yaml))
+		if err == nil {
+			assert.Equal(t, 1, len(defs))
+			_, err = defs[0].Compile()
+		}
+		if assert.Error(t, err, tc.yaml) {
+			assert.Contains(t, err.Error(), tc.err)
+		}
+	}
+
+	// JSON
+	defs, err := ParseSiteDefinitions([]byte(`{"sites":[{"name":"a","prefix":"https://example.com/","title":{"selector":"h1"},"episodes":{"selector":"li"}}]}`))
+	assert.Nil(t, err)
+	_, err = defs[0].Compile()
+	assert.Nil(t, err)
+}
+
+func TestRegistryReplace(t *testing.T) {
+	r := NewRegistry()
+	load := func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		return &Series{Key: "old"}, HttpMetadata{}, nil
+	}
+	assert.Nil(t, r.Register(NewPrefixLoader("test", "https://example.com/", load)))
+	assert.Nil(t, r.Disable("test"))
+
+	r.Replace(NewPrefixLoader("test", "https://example.org/", load))
+	r.Replace(NewPrefixLoader("other", "https://example.net/", load))
+
+	assert.Equal(t, []SiteInfo{{Name: "test", Enabled: false}, {Name: "other", Enabled: true}}, r.Sites())
+}
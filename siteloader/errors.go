@@ -0,0 +1,139 @@
+package siteloader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// UnsupportedSiteError is returned when the URL is not handled by any loader
+// or is not a valid URL for the site.
+type UnsupportedSiteError struct {
+	URL string
+	// Err is the cause. nil if no loader handles the URL.
+	Err error
+}
+
+func (e *UnsupportedSiteError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s not supported URL:%v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("%s not supported site", e.URL)
+}
+
+func (e *UnsupportedSiteError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatusError is returned when the upstream responds with an unexpected status.
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	// Body is the beginning of the response body.
+	Body string
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("%s responded %d(%s):%q", e.URL, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+	}
+	return fmt.Sprintf("%s responded %d(%s)", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// max length of HTTPStatusError.Body in bytes.
+const statusBodySnippetSize = 512
+
+// checkStatus returns HTTPStatusError if res is not 2xx.
+// The body is partially consumed in that case.
+func checkStatus(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(res.Body, statusBodySnippetSize))
+	// do not cut in the middle of a character.
+	for len(snippet) > 0 && !utf8.Valid(snippet) {
+		snippet = snippet[:len(snippet)-1]
+	}
+
+	return &HTTPStatusError{
+		URL:        res.Request.URL.String(),
+		StatusCode: res.StatusCode,
+		Body:       strings.TrimSpace(string(snippet)),
+	}
+}
+
+// LayoutError is returned when the page or API response lacks an expected
+// element, which means the scraper requires maintenance.
+type LayoutError struct {
+	Site string
+	// Selector is the CSS selector or the path of JSON which is missing or broken.
+	Selector string
+	// Err is the cause. nil if just missing.
+	Err error
+}
+
+func (e *LayoutError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s:%s is broken:%v", e.Site, e.Selector, e.Err)
+	}
+	return fmt.Sprintf("%s:%s not found", e.Site, e.Selector)
+}
+
+func (e *LayoutError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedOptionError is returned when the site does not support the site option.
+type UnsupportedOptionError struct {
+	Site   string
+	Option SiteOption
+}
+
+func (e *UnsupportedOptionError) Error() string {
+	return fmt.Sprintf("%s does not support option %s", e.Site, e.Option)
+}
+
+// NoEpisodesError is returned when the series has no (available) episodes.
+type NoEpisodesError struct {
+	Site string
+	URL  string
+}
+
+func (e *NoEpisodesError) Error() string {
+	return fmt.Sprintf("%s:no episode entry in %s", e.Site, e.URL)
+}
+
+// ErrorClass returns the short name of the class of err for logging and metrics.
+func ErrorClass(err error) string {
+	var unsupported *UnsupportedSiteError
+	var status *HTTPStatusError
+	var layout *LayoutError
+	var noEpisodes *NoEpisodesError
+	var robots *RobotsDisallowedError
+	var option *UnsupportedOptionError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotModified):
+		return "not_modified"
+	case errors.As(err, &unsupported):
+		return "unsupported"
+	case errors.As(err, &option):
+		return "unsupported_option"
+	case errors.As(err, &robots):
+		return "robots"
+	case errors.As(err, &status):
+		return "http_status"
+	case errors.As(err, &layout):
+		return "layout"
+	case errors.As(err, &noEpisodes):
+		return "no_episodes"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,119 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "not modified", err: fmt.Errorf("meteor:FetchErr:%w", ErrNotModified), want: "not_modified"},
+		{name: "unsupported", err: &UnsupportedSiteError{URL: "hoge"}, want: "unsupported"},
+		{name: "robots", err: fmt.Errorf("x:%w", &RobotsDisallowedError{URL: "hoge"}), want: "robots"},
+		{name: "http status", err: fmt.Errorf("x:%w", &HTTPStatusError{URL: "hoge", StatusCode: 503}), want: "http_status"},
+		{name: "layout", err: &LayoutError{Site: "meteor", Selector: "h2"}, want: "layout"},
+		{name: "no episodes", err: &NoEpisodesError{Site: "meteor", URL: "hoge"}, want: "no_episodes"},
+		{name: "other", err: errors.New("hoge"), want: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ErrorClass(tt.err))
+		})
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	assert.Equal(t, "hoge not supported site", (&UnsupportedSiteError{URL: "hoge"}).Error())
+	assert.Equal(t, "https://example.com/ responded 404(Not Found)",
+		(&HTTPStatusError{URL: "https://example.com/", StatusCode: 404}).Error())
+	assert.Equal(t, "meteor:h2 not found", (&LayoutError{Site: "meteor", Selector: "h2"}).Error())
+
+	cause := errors.New("cause")
+	err := &LayoutError{Site: "meteor", Selector: "h2", Err: cause}
+	assert.Equal(t, "meteor:h2 is broken:cause", err.Error())
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestUnsupportedSite(t *testing.T) {
+	var unsupported *UnsupportedSiteError
+
+	_, _, _, err := GetFeed(context.Background(), "https://www.example.com/")
+	assert.True(t, errors.As(err, &unsupported))
+	assert.Equal(t, "https://www.example.com/", unsupported.URL)
+
+	_, _, _, err = GetFeed(context.Background(), "https://comic-fuz.com/manga/hoge")
+	assert.True(t, errors.As(err, &unsupported))
+
+	_, _, _, err = GetFeed(context.Background(), "https://comic-walker.com/detail/")
+	assert.True(t, errors.As(err, &unsupported))
+}
+
+func TestLayoutError(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "example")
+	}))
+	defer testsv.Close()
+
+	testUrl, _ := url.Parse(testsv.URL)
+
+	_, _, err := meteorFeed(context.Background(), testUrl)
+	var layout *LayoutError
+	assert.True(t, errors.As(err, &layout))
+	assert.Equal(t, "meteor", layout.Site)
+	assert.Equal(t, "body > main > h2", layout.Selector)
+
+	_, _, err = kakuyomuFeed(context.Background(), testUrl)
+	assert.True(t, errors.As(err, &layout))
+	assert.Equal(t, "kakuyomu", layout.Site)
+	assert.Equal(t, "script#__NEXT_DATA__", layout.Selector)
+}
+
+func TestHTTPStatusError(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "  <html><title>お探しのページは見つかりませんでした</title></html>\n")
+	}))
+	defer testsv.Close()
+
+	testUrl, _ := url.Parse(testsv.URL + "/works/1")
+
+	_, _, err := meteorFeed(context.Background(), testUrl)
+	var status *HTTPStatusError
+	assert.True(t, errors.As(err, &status))
+	assert.Equal(t, http.StatusNotFound, status.StatusCode)
+	assert.Equal(t, testUrl.String(), status.URL)
+	assert.Equal(t, "<html><title>お探しのページは見つかりませんでした</title></html>", status.Body)
+	assert.Equal(t, "http_status", ErrorClass(err))
+}
+
+func TestCheckStatusSnippet(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		// 3 bytes per character, so the snippet is cut in the middle of one.
+		fmt.Fprint(w, strings.Repeat("あ", statusBodySnippetSize))
+	}))
+	defer testsv.Close()
+
+	res, err := http.Get(testsv.URL)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	err = checkStatus(res)
+	var status *HTTPStatusError
+	assert.True(t, errors.As(err, &status))
+	assert.Equal(t, http.StatusInternalServerError, status.StatusCode)
+	assert.Equal(t, strings.Repeat("あ", statusBodySnippetSize/3), status.Body)
+}
@@ -0,0 +1,337 @@
+package siteloader
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// BodyFunc loads the body of ep as sanitized HTML.
+type BodyFunc func(ctx context.Context, ep *Episode) (string, error)
+
+// BodyLoader is a Loader which also loads the body of episodes. (e.g. novel sites)
+type BodyLoader interface {
+	Loader
+	LoadBody(ctx context.Context, ep *Episode) (string, error)
+}
+
+type bodyLoader struct {
+	*prefixLoader
+	body BodyFunc
+}
+
+// NewBodyLoader returns a BodyLoader which handles URLs starting with prefix.
+// SiteOptionFullText is supported in addition to options.
+func NewBodyLoader(name, prefix string, load LoaderFunc, body BodyFunc, options ...SiteOption) BodyLoader {
+	options = append(slices.Clone(options), SiteOptionFullText)
+	return &bodyLoader{
+		prefixLoader: &prefixLoader{name: name, prefix: prefix, load: load, options: options},
+		body:         body,
+	}
+}
+
+func (l *bodyLoader) LoadBody(ctx context.Context, ep *Episode) (string, error) {
+	return l.body(ctx, ep)
+}
+
+type cachedBody struct {
+	// Updated is the time the episode is updated when the body is fetched.
+	Updated time.Time `json:"updated"`
+	Body    string    `json:"body"`
+}
+
+// BodyCache keeps the bodies of episodes not to fetch them again until the
+// episode is updated.
+type BodyCache struct {
+	jsonFile[map[string]*cachedBody]
+}
+
+// OpenBodyCache loads the cache from path. It is empty if the file does not exist.
+func OpenBodyCache(path string) (*BodyCache, error) {
+	c := &BodyCache{}
+	if err := c.load(path, "body cache"); err != nil {
+		return nil, err
+	}
+	for key, bodies := range c.series {
+		if bodies == nil {
+			delete(c.series, key)
+		}
+	}
+
+	return c, nil
+}
+
+// get returns the body of ep if it is cached after the last update.
+func (c *BodyCache) get(series *Series, ep *Episode) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.series[series.Key][episodeKey(ep)]
+	if !ok || !cached.Updated.Equal(episodeUpdated(ep)) {
+		return "", false
+	}
+	return cached.Body, true
+}
+
+func (c *BodyCache) put(series *Series, ep *Episode, body string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bodies, ok := c.series[series.Key]
+	if !ok {
+		bodies = make(map[string]*cachedBody)
+		c.series[series.Key] = bodies
+	}
+	bodies[episodeKey(ep)] = &cachedBody{Updated: episodeUpdated(ep), Body: body}
+	c.dirty = true
+}
+
+// prune forgets the bodies of episodes no longer in the series.
+func (c *BodyCache) prune(series *Series) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bodies, ok := c.series[series.Key]
+	if !ok {
+		return
+	}
+
+	current := make(map[string]bool, len(series.Episodes))
+	for _, ep := range series.Episodes {
+		current[episodeKey(ep)] = true
+	}
+	for key := range bodies {
+		if !current[key] {
+			delete(bodies, key)
+			c.dirty = true
+		}
+	}
+}
+
+// episodeUpdated returns the last time ep is updated.
+func episodeUpdated(ep *Episode) time.Time {
+	if ep.Updated.After(ep.Created) {
+		return ep.Updated
+	}
+	return ep.Created
+}
+
+// fullText is the setting of SetFullText. remaining is shared by the calls with the context.
+type fullText struct {
+	cache     *BodyCache
+	limited   bool
+	remaining atomic.Int64
+}
+
+func newFullText(cache *BodyCache, maxFetches int) *fullText {
+	ft := &fullText{cache: cache, limited: maxFetches > 0}
+	ft.remaining.Store(int64(maxFetches))
+	return ft
+}
+
+// take reports whether a body can be fetched within the limit.
+func (ft *fullText) take() bool {
+	return !ft.limited || ft.remaining.Add(-1) >= 0
+}
+
+type fullTextType string
+
+const fullTextKey = fullTextType("fullText")
+
+// SetFullText returns the context which makes sites supporting SiteOptionFullText
+// embed the body of episodes in the content. Bodies are taken from cache if the
+// episode is not updated, and at most maxFetches bodies are fetched with the
+// context (zero means unlimited). cache may be nil.
+func SetFullText(ctx context.Context, cache *BodyCache, maxFetches int) context.Context {
+	return context.WithValue(ctx, fullTextKey, newFullText(cache, maxFetches))
+}
+
+// WithFullText embeds the body of episodes for the call. See SetFullText. (site option)
+func WithFullText(cache *BodyCache, maxFetches int) Option {
+	return Option{site: SiteOptionFullText, apply: func(o *callOptions) { o.fullText = newFullText(cache, maxFetches) }}
+}
+
+func getFullText(ctx context.Context) *fullText {
+	if ft := getCallOptions(ctx).fullText; ft != nil {
+		return ft
+	}
+	ft, _ := ctx.Value(fullTextKey).(*fullText)
+	return ft
+}
+
+// LoadBodies loads the body of the episodes of series loaded from target later,
+// e.g. only if the series is updated. The setting of SetFullText in ctx is used,
+// or all bodies are fetched without cache if not set.
+// UnsupportedOptionError is returned if the site does not support SiteOptionFullText.
+func (r *Registry) LoadBodies(ctx context.Context, target string, series *Series) error {
+	uri, err := url.Parse(target)
+	if err != nil {
+		return &UnsupportedSiteError{URL: target, Err: err}
+	}
+
+	loader, ok := r.Lookup(uri)
+	if !ok {
+		return &UnsupportedSiteError{URL: target}
+	}
+	bl, ok := loader.(BodyLoader)
+	if !ok {
+		return &UnsupportedOptionError{Site: loader.Name(), Option: SiteOptionFullText}
+	}
+
+	ft := getFullText(ctx)
+	if ft == nil {
+		ft = newFullText(nil, 0)
+	}
+
+	ctx = SetLogger(ctx, getLogger(ctx).With("site", loader.Name(), "target", target))
+	ft.loadBodies(withSite(ctx, loader.Name()), bl, series)
+	return nil
+}
+
+// loadBodies sets the bodies of the episodes of series, the latest first.
+// Episodes whose body cannot be loaded are left without it, and false is returned.
+func (ft *fullText) loadBodies(ctx context.Context, loader BodyLoader, series *Series) bool {
+	logger := getLogger(ctx)
+	complete := true
+
+	if ft.cache != nil {
+		ft.cache.prune(series)
+	}
+
+	latest := slices.Clone(series.Episodes)
+	slices.SortStableFunc(latest, func(x, y *Episode) int {
+		return episodeUpdated(y).Compare(episodeUpdated(x))
+	})
+
+	for _, ep := range latest {
+		if ep.Unavailable {
+			continue
+		}
+		if ft.cache != nil {
+			if body, ok := ft.cache.get(series, ep); ok {
+				ep.Content = body
+				continue
+			}
+		}
+		if !ft.take() {
+			logger.Debug("body fetch limit reached", "episode", ep.Link)
+			complete = false
+			continue
+		}
+
+		body, err := loader.LoadBody(ctx, ep)
+		if err != nil {
+			logger.Warn("cannot load body", "episode", ep.Link, "error", err, "error_class", ErrorClass(err))
+			complete = false
+			continue
+		}
+		ep.Content = body
+		if ft.cache != nil {
+			ft.cache.put(series, ep, body)
+		}
+	}
+
+	return complete
+}
+
+// allowedElements are kept by sanitizeBody. Other elements are replaced with their children.
+var allowedElements = map[atom.Atom]bool{
+	atom.P: true, atom.Br: true, atom.Hr: true,
+	atom.Ruby: true, atom.Rb: true, atom.Rt: true, atom.Rp: true,
+	atom.Em: true, atom.Strong: true, atom.B: true, atom.I: true, atom.Span: true,
+	atom.Img: true,
+}
+
+// droppedElements are removed by sanitizeBody with their children.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+	atom.Object: true, atom.Embed: true, atom.Form: true, atom.Button: true,
+	atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Template: true,
+}
+
+// sanitizeBody renders the children of sel as HTML only with the text and
+// allowedElements without attributes, but src and alt of images resolved from base.
+// Ruby annotations are kept as is.
+func sanitizeBody(sel *goquery.Selection, base *url.URL) (string, error) {
+	var buf bytes.Buffer
+	for _, n := range sel.Nodes {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := renderSanitized(&buf, c, base); err != nil {
+				return "", err
+			}
+		}
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func renderSanitized(buf *bytes.Buffer, n *html.Node, base *url.URL) error {
+	switch n.Type {
+	case html.TextNode:
+		buf.WriteString(html.EscapeString(n.Data))
+		return nil
+	case html.ElementNode:
+	default:
+		// comments and so on
+		return nil
+	}
+
+	if droppedElements[n.DataAtom] {
+		return nil
+	}
+
+	renderChildren := func() error {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := renderSanitized(buf, c, base); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !allowedElements[n.DataAtom] {
+		return renderChildren()
+	}
+
+	clean := &html.Node{Type: html.ElementNode, Data: n.Data, DataAtom: n.DataAtom}
+	if n.DataAtom == atom.Img {
+		for _, attr := range n.Attr {
+			switch attr.Key {
+			case "src":
+				src, err := base.Parse(attr.Val)
+				if err != nil || (src.Scheme != "http" && src.Scheme != "https") {
+					// not to embed javascript: and so on
+					return nil
+				}
+				clean.Attr = append(clean.Attr, html.Attribute{Key: "src", Val: src.String()})
+			case "alt":
+				clean.Attr = append(clean.Attr, attr)
+			}
+		}
+		return html.Render(buf, clean)
+	}
+
+	if n.DataAtom == atom.Br || n.DataAtom == atom.Hr {
+		return html.Render(buf, clean)
+	}
+
+	// render the start and end tags around the sanitized children.
+	var tag bytes.Buffer
+	if err := html.Render(&tag, clean); err != nil {
+		return err
+	}
+	start, end, _ := strings.Cut(tag.String(), "</")
+	buf.WriteString(start)
+	if err := renderChildren(); err != nil {
+		return err
+	}
+	buf.WriteString("</" + end)
+	return nil
+}
@@ -0,0 +1,149 @@
+package siteloader
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNarouBody(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := os.Open("./testdata/narou_test_episode.html")
+		if err != nil {
+			t.Fatalf("Cannot load test file:%v", err)
+		}
+		defer f.Close()
+		io.Copy(w, f)
+	}))
+	defer testsv.Close()
+
+	body, err := narouBody(context.Background(), &Episode{Link: testsv.URL + "/n0000a/1/"})
+	assert.Nil(t, err)
+	assert.Equal(t, `<p>　<ruby>漢字<rp>(</rp><rt>かんじ</rt><rp>)</rp></ruby>のテストです。</p>
+<p><br/></p>
+<p><img src="`+testsv.URL+`/images/1.png" alt="挿絵"/></p>
+
+
+<p>&lt;終&gt;</p>`, body)
+}
+
+func TestKakuyomuBody(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := os.Open("./testdata/kakuyomu_test_episode.html")
+		if err != nil {
+			t.Fatalf("Cannot load test file:%v", err)
+		}
+		defer f.Close()
+		io.Copy(w, f)
+	}))
+	defer testsv.Close()
+
+	body, err := kakuyomuBody(context.Background(), &Episode{Link: testsv.URL + "/works/987654321/episodes/1"})
+	assert.Nil(t, err)
+	assert.Equal(t, `<p><ruby><rb>本文</rb><rp>（</rp><rt>ほんぶん</rt><rp>）</rp></ruby>です。</p>
+<p><br/></p>
+<p><em><span>傍</span><span>点</span></em></p>`, body)
+
+	_, err = narouBody(context.Background(), &Episode{Link: testsv.URL + "/works/987654321/episodes/1"})
+	var layoutErr *LayoutError
+	assert.ErrorAs(t, err, &layoutErr)
+	assert.Equal(t, "div.p-novel__body", layoutErr.Selector)
+}
+
+func TestFullText(t *testing.T) {
+	var fetches atomic.Int32
+	r := NewRegistry()
+	updated := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	loadSeries := func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		return &Series{Key: "test", Episodes: []*Episode{
+			{ID: "1", Link: "https://example.com/1", Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{ID: "2", Link: "https://example.com/2", Created: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Updated: updated},
+			{ID: "3", Link: "https://example.com/3", Created: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		}}, HttpMetadata{}, nil
+	}
+	assert.Nil(t, r.Register(NewBodyLoader("novel", "https://example.com/", loadSeries, func(ctx context.Context, ep *Episode) (string, error) {
+		fetches.Add(1)
+		return "<p>" + ep.ID + "</p>", nil
+	})))
+	assert.Nil(t, r.Register(NewPrefixLoader("comic", "https://example.net/", loadSeries)))
+
+	// not loaded by default
+	series, _, err := r.GetSeries(context.Background(), "https://example.com/series")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), fetches.Load())
+	assert.Equal(t, "", series.Episodes[0].Content)
+
+	_, _, err = r.GetSeriesWithOptions(context.Background(), "https://example.net/series", WithFullText(nil, 0))
+	var unsupported *UnsupportedOptionError
+	assert.ErrorAs(t, err, &unsupported)
+
+	path := filepath.Join(t.TempDir(), "bodies.json")
+	cache, err := OpenBodyCache(path)
+	assert.Nil(t, err)
+
+	// the latest first within the limit
+	series, metadata, err := r.GetSeriesWithOptions(context.Background(), "https://example.com/series", WithFullText(cache, 2))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+	// no validators until all bodies are loaded
+	assert.Equal(t, "", metadata.ETag)
+	assert.Equal(t, "", metadata.LastModified)
+	assert.Equal(t, "", series.Episodes[0].Content)
+	assert.Equal(t, "<p>2</p>", series.Episodes[1].Content)
+	assert.Equal(t, "<p>3</p>", series.Episodes[2].Content)
+	assert.Nil(t, cache.Save())
+
+	feed := series.Feed()
+	assert.Equal(t, "<p>3</p>", feed.Items[2].Content)
+	assert.Equal(t, "<p>3</p>", series.JSONFeed().Items[2].ContentHTML)
+
+	// cached bodies are not counted
+	cache, err = OpenBodyCache(path)
+	assert.Nil(t, err)
+	ctx := SetFullText(context.Background(), cache, 1)
+	series, metadata, err = r.GetSeries(ctx, "https://example.com/series")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), fetches.Load())
+	assert.NotEmpty(t, metadata.ETag)
+	assert.Equal(t, "<p>1</p>", series.Episodes[0].Content)
+	assert.Equal(t, "<p>2</p>", series.Episodes[1].Content)
+	assert.Nil(t, cache.Save())
+
+	// the limit is shared by the calls with the context
+	ctx = SetFullText(context.Background(), nil, 1)
+	series, _, err = r.GetSeries(ctx, "https://example.com/series")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), fetches.Load())
+	assert.Equal(t, "<p>2</p>", series.Episodes[1].Content)
+	series, _, err = r.GetSeries(ctx, "https://example.com/series")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), fetches.Load())
+	assert.Equal(t, "", series.Episodes[1].Content)
+
+	// fetched again when the episode is updated
+	updated = updated.Add(time.Hour)
+	fetches.Store(0)
+	cache, err = OpenBodyCache(path)
+	assert.Nil(t, err)
+	_, _, err = r.GetSeriesWithOptions(context.Background(), "https://example.com/series", WithFullText(cache, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// loaded after the series
+	series, _, err = r.GetSeries(context.Background(), "https://example.com/series")
+	assert.Nil(t, err)
+	assert.Nil(t, r.LoadBodies(context.Background(), "https://example.com/series", series))
+	assert.Equal(t, int32(4), fetches.Load())
+	assert.Equal(t, "<p>1</p>", series.Episodes[0].Content)
+
+	assert.ErrorAs(t, r.LoadBodies(context.Background(), "https://example.net/series", series), &unsupported)
+}
@@ -12,30 +12,30 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gorilla/feeds"
 	"google.golang.org/protobuf/proto"
 )
 
-func fuzFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMetadata, error) {
+func fuzFeed(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
 	idx := strings.LastIndex(target.Path, "/")
 	idStr := target.Path[idx+1:]
-	freeOnly := target.Query().Has("freeOnly")
+	// ?freeOnly is kept for compatibility with WithFreeOnly.
+	freeOnly := target.Query().Has("freeOnly") || getCallOptions(ctx).freeOnly
 
 	tq := target.Query()
 	tq.Del("freeOnly")
 	target.RawQuery = tq.Encode()
 
 	if idStr == "" {
-		return "", nil, HttpMetadata{}, errors.New("fuz:invalid URI")
+		return nil, HttpMetadata{}, &UnsupportedSiteError{URL: target.String(), Err: errors.New("manga id not found")}
 	}
 
 	id64, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("fuz:invalid id: %w", err)
+		return nil, HttpMetadata{}, &UnsupportedSiteError{URL: target.String(), Err: err}
 	}
 
 	mangaId := uint32(id64)
-	fmt.Printf("fuz: mangaId=%d, freeOnly=%t\n", mangaId, freeOnly)
+	getLogger(ctx).Debug("fuz manga", "manga_id", mangaId, "free_only", freeOnly)
 
 	mdReq := &MangaDetailRequest{
 		MangaId: &mangaId,
@@ -46,33 +46,44 @@ func fuzFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMet
 
 	req, err := proto.Marshal(mdReq)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("fuz:failure to marshal request: %w", err)
+		return nil, HttpMetadata{}, fmt.Errorf("fuz:failure to marshal request: %w", err)
 	}
 
-	res, err := http.Post("https://api.comic-fuz.com/v1/manga_detail", "application/protobuf", bytes.NewReader(req))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.comic-fuz.com/v1/manga_detail", bytes.NewReader(req))
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("fuz:failure to post request: %w", err)
+		return nil, HttpMetadata{}, fmt.Errorf("fuz:cannot generate request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/protobuf")
+	setConditionalHeaders(ctx, httpReq)
+
+	res, err := getClient(ctx).Do(httpReq)
+	if err != nil {
+		return nil, HttpMetadata{}, fmt.Errorf("fuz:failure to post request: %w", err)
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusNotModified {
+		return nil, HttpMetadata{}, fmt.Errorf("fuz:%w", ErrNotModified)
+	}
+
 	metadata := HttpMetadata{
 		ETag:         res.Header.Get("ETag"),
 		LastModified: res.Header.Get("Last-Modified"),
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return "", nil, metadata, fmt.Errorf("fuz:server failed: %d(%s)", res.StatusCode, http.StatusText(res.StatusCode))
+	if err := checkStatus(res); err != nil {
+		return nil, metadata, fmt.Errorf("fuz:%w", err)
 	}
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("fuz:failure to read request: %w", err)
+		return nil, metadata, fmt.Errorf("fuz:failure to read request: %w", err)
 	}
 
 	data := &MangaDetailResponse{}
 
 	if err = proto.Unmarshal(body, data); err != nil {
-		return "", nil, metadata, fmt.Errorf("fuz:failure to unmarshal response: %w", err)
+		return nil, metadata, &LayoutError{Site: "fuz", Selector: "MangaDetailResponse", Err: err}
 	}
 
 	var authors []string
@@ -82,27 +93,39 @@ func fuzFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMet
 		}
 	}
 
-	loc, err := time.LoadLocation("Asia/Tokyo")
+	loc, err := siteLocation(ctx)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("fuz:failure to load Asia/Tokyo timezone: %w", err)
+		return nil, metadata, fmt.Errorf("fuz:%w", err)
 	}
 
 	latestUpdate, err := time.ParseInLocation("2006/01/02", data.Manga.LatestUpdatedDate, loc)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("fuz:failure to parse LatestUpdatedDate[%s]: %w", data.Manga.LatestUpdatedDate, err)
+		return nil, metadata, &LayoutError{Site: "fuz", Selector: "MangaDetailResponse.manga.latestUpdatedDate", Err: err}
+	}
+
+	freeOnlyPrefix := ""
+	if freeOnly {
+		freeOnlyPrefix = "_freeOnly"
 	}
 
-	feed := &feeds.Feed{
+	series := &Series{
+		Site:        "fuz",
+		Key:         "fuz_" + escapePath(target.Path) + freeOnlyPrefix,
 		Title:       data.Manga.MangaName,
-		Link:        &feeds.Link{Href: target.String()},
+		Link:        target.String(),
 		Description: data.Manga.LongDescription,
-		Author:      &feeds.Author{Name: strings.Join(authors, "/")},
+		Author:      strings.Join(authors, "/"),
+		Thumbnail:   data.Manga.MainThumbnailUrl,
 		Created:     latestUpdate,
 	}
 
 	for _, cg := range data.Chapters {
 		for _, c := range cg.Chapters {
-			if freeOnly && c.PointConsumption.GetAmount() != 0 {
+			pricing := PricingFree
+			if c.PointConsumption.GetAmount() != 0 {
+				pricing = PricingPaid
+			}
+			if freeOnly && pricing != PricingFree {
 				continue
 			}
 
@@ -116,19 +139,25 @@ func fuzFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMet
 				continue
 			}
 			href := fmt.Sprintf("https://comic-fuz.com/manga/viewer/%d", c.ChapterId)
-			feed.Items = append(feed.Items, &feeds.Item{
-				Title:   title,
-				Updated: at,
-				Link:    &feeds.Link{Href: href},
-				Id:      generateHashedHex(href),
+			series.Episodes = append(series.Episodes, &Episode{
+				ID:        generateHashedHex(href),
+				Title:     title,
+				Link:      href,
+				Thumbnail: c.ThumbnailUrl,
+				Pricing:   pricing,
+				Updated:   at,
 			})
 		}
 	}
 
-	freeOnlyPrefix := ""
-	if freeOnly {
-		freeOnlyPrefix = "_freeOnly"
+	if len(series.Episodes) == 0 {
+		return nil, metadata, &NoEpisodesError{Site: "fuz", URL: target.String()}
+	}
+
+	fillValidators(&metadata, hashETag(series.Key, body), latestUpdate)
+	if err := checkNotModified(ctx, metadata); err != nil {
+		return nil, metadata, fmt.Errorf("fuz:%w", err)
 	}
 
-	return "fuz_" + escapePath(target.Path) + freeOnlyPrefix, feed, metadata, nil
+	return series, metadata, nil
 }
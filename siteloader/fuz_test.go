@@ -0,0 +1,231 @@
+package siteloader
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func newFuzTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/manga_detail" {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("cannot read request:%v", err)
+		}
+		mdReq := &MangaDetailRequest{}
+		if err := proto.Unmarshal(body, mdReq); err != nil {
+			t.Fatalf("cannot unmarshal request:%v", err)
+		}
+
+		var res []byte
+		switch mdReq.GetMangaId() {
+		case 1234:
+			res, err = proto.Marshal(&MangaDetailResponse{
+				Manga: &Manga{
+					MangaId:           1234,
+					MangaName:         "テストタイトル",
+					MainThumbnailUrl:  "https://example.com/main.jpg",
+					LongDescription:   "テストてすとストーリー",
+					LatestUpdatedDate: "2024/06/30",
+				},
+				Authorship: []*Authorship{
+					{Author: []*Authorship_Author{{AuthorName: "テスト原作"}}, Role: "原作"},
+					{Author: []*Authorship_Author{{AuthorName: "テスト著者"}}, Role: "漫画"},
+				},
+				Chapters: []*ChapterGroup{
+					{
+						Chapters: []*Chapter{
+							{
+								ChapterId:        111,
+								ChapterMainName:  "第1話",
+								ChapterSubName:   "サブタイトル1",
+								ThumbnailUrl:     "https://example.com/111.jpg",
+								PointConsumption: &Chapter_PointConsumption{Amount: 0},
+								UpdatedDate:      "2024/06/01",
+							},
+							{
+								ChapterId:        222,
+								ChapterMainName:  "第2話",
+								ThumbnailUrl:     "https://example.com/222.jpg",
+								PointConsumption: &Chapter_PointConsumption{Amount: 30},
+								UpdatedDate:      "2024/06/15",
+							},
+							{
+								ChapterId:        333,
+								ChapterMainName:  "第3話",
+								ThumbnailUrl:     "https://example.com/333.jpg",
+								PointConsumption: &Chapter_PointConsumption{Amount: 0},
+								UpdatedDate:      "2024/06/30",
+							},
+						},
+					},
+				},
+			})
+		case 5678:
+			// no free chapters
+			res, err = proto.Marshal(&MangaDetailResponse{
+				Manga: &Manga{
+					MangaId:           5678,
+					MangaName:         "有料タイトル",
+					LatestUpdatedDate: "2024/06/30",
+				},
+				Chapters: []*ChapterGroup{
+					{
+						Chapters: []*Chapter{
+							{
+								ChapterId:        555,
+								ChapterMainName:  "第1話",
+								PointConsumption: &Chapter_PointConsumption{Amount: 30},
+								UpdatedDate:      "2024/06/30",
+							},
+						},
+					},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			t.Fatalf("cannot marshal response:%v", err)
+		}
+		w.Header().Set("Content-Type", "application/protobuf")
+		w.Write(res)
+	}))
+}
+
+func TestFuz(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+
+	testsv := newFuzTestServer(t)
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	testUrl, _ := url.Parse("https://comic-fuz.com/manga/1234")
+	series, _, err := fuzFeed(ctx, testUrl)
+	assert.Nil(t, err)
+	feed := series.Feed()
+
+	assert.Equal(t, "fuz_manga1234", series.Key)
+
+	assert.Equal(t, "テストタイトル", feed.Title)
+	assert.Equal(t, "https://comic-fuz.com/manga/1234", feed.Link.Href)
+	assert.Equal(t, "テスト原作/テスト著者", feed.Author.Name)
+	assert.Equal(t, "テストてすとストーリー", feed.Description)
+	assert.Equal(t, "https://example.com/main.jpg", series.Thumbnail)
+
+	wantTime := time.Date(2024, 6, 30, 0, 0, 0, 0, jst)
+	assert.True(t, wantTime.Equal(feed.Created),
+		"(created)want %v,got %v", wantTime, feed.Created)
+
+	testcases := []struct {
+		path    string
+		title   string
+		pricing Pricing
+		updated time.Time
+	}{
+		{
+			path:    "https://comic-fuz.com/manga/viewer/111",
+			title:   "第1話/サブタイトル1",
+			pricing: PricingFree,
+			updated: time.Date(2024, 6, 1, 0, 0, 0, 0, jst),
+		},
+		{
+			path:    "https://comic-fuz.com/manga/viewer/222",
+			title:   "第2話",
+			pricing: PricingPaid,
+			updated: time.Date(2024, 6, 15, 0, 0, 0, 0, jst),
+		},
+		{
+			path:    "https://comic-fuz.com/manga/viewer/333",
+			title:   "第3話",
+			pricing: PricingFree,
+			updated: time.Date(2024, 6, 30, 0, 0, 0, 0, jst),
+		},
+	}
+
+	for index, tt := range testcases {
+		t.Run(tt.title, func(t *testing.T) {
+			assert.Equal(t, generateHashedHex(tt.path), series.Episodes[index].ID)
+			assert.Equal(t, tt.path, feed.Items[index].Link.Href)
+			assert.Equal(t, tt.title, feed.Items[index].Title)
+			assert.Equal(t, tt.pricing, series.Episodes[index].Pricing)
+
+			assert.True(t, tt.updated.Equal(feed.Items[index].Updated),
+				"(updated)want %v,got %v", tt.updated, feed.Items[index].Updated)
+		})
+	}
+	assert.Panics(t, func() { _ = feed.Items[3].Title })
+}
+
+func TestFuzFreeOnly(t *testing.T) {
+	testsv := newFuzTestServer(t)
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	testUrl, _ := url.Parse("https://comic-fuz.com/manga/1234?freeOnly")
+	series, _, err := fuzFeed(ctx, testUrl)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "fuz_manga1234_freeOnly", series.Key)
+	assert.Equal(t, "https://comic-fuz.com/manga/1234", series.Link)
+
+	assert.Equal(t, 2, len(series.Episodes))
+	assert.Equal(t, "https://comic-fuz.com/manga/viewer/111", series.Episodes[0].Link)
+	assert.Equal(t, "https://comic-fuz.com/manga/viewer/333", series.Episodes[1].Link)
+}
+
+func TestFuzNoFreeEpisodes(t *testing.T) {
+	testsv := newFuzTestServer(t)
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	testUrl, _ := url.Parse("https://comic-fuz.com/manga/5678")
+	series, _, err := fuzFeed(ctx, testUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(series.Episodes))
+
+	testUrl, _ = url.Parse("https://comic-fuz.com/manga/5678?freeOnly")
+	series, _, err = fuzFeed(ctx, testUrl)
+	var noEpisodes *NoEpisodesError
+	assert.ErrorAs(t, err, &noEpisodes)
+	assert.Equal(t, "fuz", noEpisodes.Site)
+	assert.Nil(t, series)
+}
+
+func TestFuzErr(t *testing.T) {
+	testsv := newFuzTestServer(t)
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	for _, target := range []string{
+		"https://comic-fuz.com/manga/",
+		"https://comic-fuz.com/manga/hoge",
+		"https://comic-fuz.com/manga/9999",
+	} {
+		t.Run(target, func(t *testing.T) {
+			testUrl, _ := url.Parse(target)
+			series, _, err := fuzFeed(ctx, testUrl)
+			assert.Error(t, err)
+			assert.Nil(t, series)
+		})
+	}
+}
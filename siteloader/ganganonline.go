@@ -3,23 +3,19 @@ package siteloader
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/url"
-	"time"
-
-	"github.com/gorilla/feeds"
 )
 
-func ganganonlineFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMetadata, error) {
+func ganganonlineFeed(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
 	doc, metadata, err := fetchDocument(ctx, target)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("ganganonline:FetchErr:%w", err)
+		return nil, metadata, fmt.Errorf("ganganonline:FetchErr:%w", err)
 	}
 
 	script := doc.Find("script#__NEXT_DATA__").Text()
 	if script == "" {
-		return "", nil, metadata, errors.New("ganganonline:__NEXT_DATA__ not found")
+		return nil, metadata, &LayoutError{Site: "ganganonline", Selector: "script#__NEXT_DATA__"}
 	}
 
 	var ganganonlineNextData struct {
@@ -49,17 +45,19 @@ func ganganonlineFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed
 	}
 
 	if err := json.Unmarshal([]byte(script), &ganganonlineNextData); err != nil {
-		return "", nil, metadata, fmt.Errorf("ganganonline:__NEXT_DATA__ parse error %w", err)
+		return nil, metadata, &LayoutError{Site: "ganganonline", Selector: "script#__NEXT_DATA__", Err: err}
 	}
 
 	defaultData := ganganonlineNextData.Props.PageProps.Data.Default
 
-	feed := &feeds.Feed{
+	series := &Series{
+		Site:        "ganganonline",
+		Key:         fmt.Sprintf("ganganonline_%d", defaultData.TitleID),
 		Title:       defaultData.TitleName,
-		Link:        &feeds.Link{Href: target.String()},
+		Link:        target.String(),
 		Description: trimDescription(defaultData.Description),
-		Author:      &feeds.Author{Name: defaultData.Author},
-		Created:     time.Now(),
+		Author:      defaultData.Author,
+		Thumbnail:   defaultData.ImageURL,
 	}
 
 	for _, chapter := range defaultData.Chapters {
@@ -67,16 +65,17 @@ func ganganonlineFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed
 			continue
 		}
 		uri := fmt.Sprintf("https://www.ganganonline.com/title/%d/chapter/%d", defaultData.TitleID, chapter.ID)
-		feed.Items = append(feed.Items, &feeds.Item{
-			Title: chapter.MainText,
-			Link:  &feeds.Link{Href: uri},
-			Id:    generateHashedHex(uri),
+		series.Episodes = append(series.Episodes, &Episode{
+			ID:        generateHashedHex(uri),
+			Title:     chapter.MainText,
+			Link:      uri,
+			Thumbnail: chapter.ThumbnailURL,
 		})
 	}
 
-	if len(feed.Items) == 0 {
-		return "", nil, metadata, fmt.Errorf("ganganonline:no episode entry")
+	if len(series.Episodes) == 0 {
+		return nil, metadata, &NoEpisodesError{Site: "ganganonline", URL: target.String()}
 	}
 
-	return fmt.Sprintf("ganganonline_%d", defaultData.TitleID), feed, metadata, nil
+	return series, metadata, nil
 }
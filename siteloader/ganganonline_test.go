@@ -27,10 +27,11 @@ func TestGanganOnline(t *testing.T) {
 
 	testUrl, _ := url.Parse(testsv.URL + "/path_t/est")
 
-	fname, feed, _, err := ganganonlineFeed(context.Background(), testUrl)
+	series, _, err := ganganonlineFeed(context.Background(), testUrl)
 	assert.Nil(t, err)
+	feed := series.Feed()
 
-	assert.Equal(t, "ganganonline_12345", fname)
+	assert.Equal(t, "ganganonline_12345", series.Key)
 
 	assert.Equal(t, "テストタイトル", feed.Title)
 	assert.Equal(t, testUrl.String(), feed.Link.Href)
@@ -59,7 +60,7 @@ func TestGanganOnline(t *testing.T) {
 		t.Run(tt.title, func(t *testing.T) {
 			assert.Equal(t, tt.path, feed.Items[index].Link.Href)
 			assert.Equal(t, tt.title, feed.Items[index].Title)
-			assert.Equal(t, generateHashedHex(tt.path), feed.Items[index].Id)
+			assert.Equal(t, generateHashedHex(tt.path), series.Episodes[index].ID)
 		})
 	}
 
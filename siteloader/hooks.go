@@ -0,0 +1,126 @@
+package siteloader
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Hooks receives events of fetches and loads, e.g. to collect metrics.
+// nil funcs are ignored. funcs may be called concurrently.
+type Hooks struct {
+	// FetchStart is called when Client starts a request. retries are not reported.
+	FetchStart func(FetchStartEvent)
+	// FetchEnd is called when the response body is closed or the request failed.
+	FetchEnd func(FetchEndEvent)
+	// Load is called when a series is loaded by the registry.
+	Load func(LoadEvent)
+}
+
+// FetchStartEvent is the event of Hooks.FetchStart.
+type FetchStartEvent struct {
+	// Site is the name of the loader. empty if fetched outside of the registry.
+	Site string
+	URL  string
+}
+
+// FetchEndEvent is the event of Hooks.FetchEnd.
+type FetchEndEvent struct {
+	Site string
+	URL  string
+	// Status is zero if no response is received.
+	Status int
+	// Bytes is the length of the body read.
+	Bytes    int64
+	Duration time.Duration
+	Err      error
+}
+
+// LoadEvent is the event of Hooks.Load.
+type LoadEvent struct {
+	Site   string
+	Target string
+	// Items is the number of episodes in the feed.
+	Items       int
+	NotModified bool
+	Duration    time.Duration
+	// Err is nil if loaded or not modified.
+	Err error
+}
+
+const hooksKey = hooksType("Hooks")
+
+type hooksType string
+
+// SetHooks returns the context which makes the library call h.
+func SetHooks(ctx context.Context, h *Hooks) context.Context {
+	if h == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, hooksKey, h)
+}
+
+// WithHooks makes the call call h. See SetHooks.
+func WithHooks(h *Hooks) Option {
+	return Option{apply: func(o *callOptions) { o.hooks = h }}
+}
+
+func getHooks(ctx context.Context) *Hooks {
+	if h, ok := ctx.Value(hooksKey).(*Hooks); ok {
+		return h
+	}
+	return &Hooks{}
+}
+
+func (h *Hooks) fetchStart(ev FetchStartEvent) {
+	if h.FetchStart != nil {
+		h.FetchStart(ev)
+	}
+}
+
+func (h *Hooks) fetchEnd(ev FetchEndEvent) {
+	if h.FetchEnd != nil {
+		h.FetchEnd(ev)
+	}
+}
+
+func (h *Hooks) load(ev LoadEvent) {
+	if h.Load != nil {
+		h.Load(ev)
+	}
+}
+
+const siteKey = siteType("Site")
+
+type siteType string
+
+// withSite records the name of the loader to report in events.
+func withSite(ctx context.Context, site string) context.Context {
+	return context.WithValue(ctx, siteKey, site)
+}
+
+func getSite(ctx context.Context) string {
+	site, _ := ctx.Value(siteKey).(string)
+	return site
+}
+
+// countedBody calls onClose with the bytes read when the body is closed.
+type countedBody struct {
+	io.ReadCloser
+	bytes   int64
+	onClose func(bytes int64)
+	once    sync.Once
+}
+
+func (b *countedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	return n, err
+}
+
+func (b *countedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.onClose(b.bytes) })
+	return err
+}
@@ -0,0 +1,84 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "example")
+	}))
+	defer testsv.Close()
+
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", testsv.URL, func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		if _, _, err := fetchDocument(ctx, target); err != nil {
+			return nil, HttpMetadata{}, err
+		}
+		return &Series{Key: "test", Episodes: []*Episode{{ID: "ep1"}, {ID: "ep2"}}}, HttpMetadata{}, nil
+	})))
+
+	var mu sync.Mutex
+	var starts []FetchStartEvent
+	var ends []FetchEndEvent
+	var loads []LoadEvent
+	hooks := &Hooks{
+		FetchStart: func(ev FetchStartEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			starts = append(starts, ev)
+		},
+		FetchEnd: func(ev FetchEndEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			ends = append(ends, ev)
+		},
+		Load: func(ev LoadEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			loads = append(loads, ev)
+		},
+	}
+
+	_, _, metadata, err := r.GetFeedWithOptions(context.Background(), testsv.URL+"/1", WithHooks(hooks))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []FetchStartEvent{{Site: "test", URL: testsv.URL + "/1"}}, starts)
+	assert.Equal(t, 1, len(ends))
+	assert.Equal(t, "test", ends[0].Site)
+	assert.Equal(t, http.StatusOK, ends[0].Status)
+	assert.Equal(t, int64(7), ends[0].Bytes)
+	assert.Nil(t, ends[0].Err)
+	assert.Equal(t, 1, len(loads))
+	assert.Equal(t, "test", loads[0].Site)
+	assert.Equal(t, testsv.URL+"/1", loads[0].Target)
+	assert.Equal(t, 2, loads[0].Items)
+	assert.False(t, loads[0].NotModified)
+
+	// not modified
+	ctx := SetHooks(context.Background(), hooks)
+	_, _, _, err = r.GetFeedWithOptions(ctx, testsv.URL+"/1", WithIfNoneMatch(metadata.ETag))
+	assert.True(t, errors.Is(err, ErrNotModified))
+	assert.True(t, loads[1].NotModified)
+	assert.Nil(t, loads[1].Err)
+
+	// failure
+	_, _, _, err = r.GetFeed(ctx, testsv.URL+"/missing")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, ends[2].Status)
+	assert.Equal(t, 0, loads[2].Items)
+	assert.Equal(t, "http_status", ErrorClass(loads[2].Err))
+}
@@ -0,0 +1,135 @@
+package siteloader
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// jsonFeedVersion is the version URL of JSON Feed 1.1.
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+// JSONFeed is a JSON Feed 1.1 document. https://www.jsonfeed.org/version/1.1/
+type JSONFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Icon        string           `json:"icon,omitempty"`
+	Authors     []JSONFeedAuthor `json:"authors,omitempty"`
+	Items       []JSONFeedItem   `json:"items"`
+	// Extension holds the data of the series not in JSON Feed.
+	Extension *JSONFeedSeriesExtension `json:"_comic2atom,omitempty"`
+}
+
+// JSONFeedAuthor is an author of JSONFeed.
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// JSONFeedItem is an item of JSONFeed.
+type JSONFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentText   string           `json:"content_text"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished *time.Time       `json:"date_published,omitempty"`
+	DateModified  *time.Time       `json:"date_modified,omitempty"`
+	Authors       []JSONFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	// Extension holds the data of the episode not in JSON Feed.
+	Extension *JSONFeedItemExtension `json:"_comic2atom,omitempty"`
+}
+
+// JSONFeedSeriesExtension is the _comic2atom object of JSONFeed.
+type JSONFeedSeriesExtension struct {
+	Site      string `json:"site"`
+	Key       string `json:"key"`
+	ID        string `json:"id,omitempty"`
+	Completed bool   `json:"completed,omitempty"`
+}
+
+// JSONFeedItemExtension is the _comic2atom object of JSONFeedItem.
+type JSONFeedItemExtension struct {
+	// ID is the ID of the episode in the site.
+	ID      string `json:"id,omitempty"`
+	Chapter string `json:"chapter,omitempty"`
+	Number  int    `json:"number,omitempty"`
+	// Pricing is free, paid or unknown.
+	Pricing     string     `json:"pricing"`
+	FreeUntil   *time.Time `json:"free_until,omitempty"`
+	Unavailable bool       `json:"unavailable,omitempty"`
+}
+
+// JSONFeed renders the series as JSON Feed 1.1. IDs are always tag: URIs
+// as JSON Feed was not rendered by former versions.
+func (s *Series) JSONFeed() *JSONFeed {
+	optionalTime := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+
+	var authors []JSONFeedAuthor
+	if s.Author != "" {
+		authors = []JSONFeedAuthor{{Name: s.Author}}
+	}
+
+	feed := &JSONFeed{
+		Version:     jsonFeedVersion,
+		Title:       s.Title,
+		HomePageURL: s.Link,
+		Description: s.Description,
+		Icon:        s.Thumbnail,
+		Authors:     authors,
+		Items:       make([]JSONFeedItem, 0, len(s.Episodes)),
+		Extension: &JSONFeedSeriesExtension{
+			Site:      s.Site,
+			Key:       s.Key,
+			ID:        s.ID,
+			Completed: s.Completed,
+		},
+	}
+
+	for _, ep := range s.Episodes {
+		item := JSONFeedItem{
+			ID:            s.EpisodeTagURI(ep),
+			URL:           ep.Link,
+			Title:         ep.FullTitle(),
+			ContentText:   ep.entryDescription(),
+			ContentHTML:   ep.Content,
+			Image:         ep.Thumbnail,
+			DatePublished: optionalTime(ep.Created),
+			DateModified:  optionalTime(ep.Updated),
+			Authors:       authors,
+			Extension: &JSONFeedItemExtension{
+				ID:          ep.ID,
+				Chapter:     ep.Chapter,
+				Number:      ep.Number,
+				Pricing:     ep.Pricing.String(),
+				FreeUntil:   optionalTime(ep.FreeUntil),
+				Unavailable: ep.Unavailable,
+			},
+		}
+		if ep.Chapter != "" {
+			item.Tags = append(item.Tags, ep.Chapter)
+		}
+		if ep.Pricing != PricingUnknown {
+			item.Tags = append(item.Tags, ep.Pricing.String())
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed
+}
+
+// ToJSONFeed renders the series as JSON Feed 1.1. See Series.JSONFeed.
+func ToJSONFeed(s *Series) (string, error) {
+	b, err := json.MarshalIndent(s.JSONFeed(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
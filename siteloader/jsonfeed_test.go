@@ -0,0 +1,90 @@
+package siteloader
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToJSONFeed(t *testing.T) {
+	series := &Series{
+		Site:        "test",
+		Key:         "test_1",
+		ID:          "feedid",
+		Title:       "テストタイトル",
+		Link:        "https://example.com/1",
+		Description: "テストストーリー",
+		Author:      "テスト著者",
+		Episodes: []*Episode{
+			{
+				ID:        "ep1",
+				Title:     "サブタイトル1",
+				Link:      "https://example.com/1/1",
+				Chapter:   "チャプター1",
+				Number:    1,
+				Thumbnail: "https://example.com/1/1.jpg",
+				Pricing:   PricingFree,
+				FreeUntil: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				Created:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Updated:   time.Date(2024, 1, 3, 3, 4, 5, 0, time.UTC),
+			},
+			{
+				Title:       "サブタイトル2",
+				Link:        "https://example.com/1/2",
+				Description: "説明",
+				Unavailable: true,
+			},
+		},
+	}
+
+	// legacy IDs are not used for JSON Feed
+	rendered, err := Render(series, FormatJSON, true)
+	assert.Nil(t, err)
+
+	expected := `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "テストタイトル",
+  "home_page_url": "https://example.com/1",
+  "description": "テストストーリー",
+  "authors": [{"name": "テスト著者"}],
+  "items": [
+    {
+      "id": "tag:3pf.jp,2024:comic2atom/test_1/ep1",
+      "url": "https://example.com/1/1",
+      "title": "チャプター1/サブタイトル1",
+      "content_text": "",
+      "image": "https://example.com/1/1.jpg",
+      "date_published": "2024-01-02T03:04:05Z",
+      "date_modified": "2024-01-03T03:04:05Z",
+      "authors": [{"name": "テスト著者"}],
+      "tags": ["チャプター1", "free"],
+      "_comic2atom": {
+        "id": "ep1",
+        "chapter": "チャプター1",
+        "number": 1,
+        "pricing": "free",
+        "free_until": "2024-02-01T00:00:00Z"
+      }
+    },
+    {
+      "id": "tag:3pf.jp,2024:comic2atom/test_1/` + generateHashedHex("https://example.com/1/2") + `",
+      "url": "https://example.com/1/2",
+      "title": "サブタイトル2",
+      "content_text": "(公開終了) 説明",
+      "authors": [{"name": "テスト著者"}],
+      "_comic2atom": {
+        "pricing": "unknown",
+        "unavailable": true
+      }
+    }
+  ],
+  "_comic2atom": {"site": "test", "key": "test_1", "id": "feedid"}
+}`
+	assert.JSONEq(t, expected, rendered)
+
+	var feed JSONFeed
+	assert.Nil(t, json.Unmarshal([]byte(rendered), &feed))
+	assert.Equal(t, series.JSONFeed(), &feed)
+}
@@ -0,0 +1,95 @@
+package siteloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// jsonFile is embedded in the stores persisted as a JSON file, which keep
+// a value of T for each series keyed by Series.Key. The stores lock mu while
+// accessing series and set dirty when they change it, so that Save writes the
+// file only if needed. It is safe for concurrent use.
+type jsonFile[T any] struct {
+	path string
+	// name of the store in errors.
+	name string
+
+	mu     sync.Mutex
+	series map[string]T
+	dirty  bool
+}
+
+// load reads the series from path. They are empty if the file does not exist.
+func (f *jsonFile[T]) load(path, name string) error {
+	f.path = path
+	f.name = name
+	f.series = make(map[string]T)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read %s:%w", name, err)
+	}
+
+	var file struct {
+		Series map[string]T `json:"series"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("cannot parse %s %q:%w", name, path, err)
+	}
+	for key, value := range file.Series {
+		f.series[key] = value
+	}
+
+	return nil
+}
+
+// Save writes the file if it is changed.
+func (f *jsonFile[T]) Save() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(struct {
+		Series map[string]T `json:"series"`
+	}{f.series})
+	if err != nil {
+		return fmt.Errorf("cannot marshal %s:%w", f.name, err)
+	}
+
+	if err := writeFileAtomic(f.path, data); err != nil {
+		return fmt.Errorf("cannot save %s:%w", f.name, err)
+	}
+
+	f.dirty = false
+	return nil
+}
+
+// writeFileAtomic writes data to the temporary file and renames it to path
+// not to break the file on crash.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,40 @@
+package siteloader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.json")
+
+	var f jsonFile[[]string]
+	assert.Nil(t, f.load(path, "test"))
+	assert.Equal(t, 0, len(f.series))
+
+	// not written unless changed
+	assert.Nil(t, f.Save())
+	_, err := os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	f.series["test_1"] = []string{"ep1"}
+	f.dirty = true
+	assert.Nil(t, f.Save())
+	assert.False(t, f.dirty)
+
+	var loaded jsonFile[[]string]
+	assert.Nil(t, loaded.load(path, "test"))
+	assert.Equal(t, map[string][]string{"test_1": {"ep1"}}, loaded.series)
+
+	assert.Nil(t, os.WriteFile(path, []byte("{"), 0644))
+	err = loaded.load(path, "test")
+	assert.ErrorContains(t, err, "cannot parse test")
+
+	// no temporary file is left
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+}
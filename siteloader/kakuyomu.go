@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/gorilla/feeds"
 )
 
 func getTimFromObj(i interface{}) (time.Time, error) {
@@ -27,15 +26,15 @@ func getTimFromObj(i interface{}) (time.Time, error) {
 	return time.Parse(time.RFC3339, t)
 }
 
-func kakuyomuFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMetadata, error) {
+func kakuyomuFeed(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
 	doc, metadata, err := fetchDocument(ctx, target)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("kakuyomu:FetchErr:%w", err)
+		return nil, metadata, fmt.Errorf("kakuyomu:FetchErr:%w", err)
 	}
 
 	script := doc.Find("script#__NEXT_DATA__").Text()
 	if script == "" {
-		return "", nil, metadata, errors.New("kakuyomu:__NEXT_DATA__ not found")
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "script#__NEXT_DATA__"}
 	}
 
 	var kakuyomuNextData struct {
@@ -50,59 +49,61 @@ func kakuyomuFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, Ht
 	}
 
 	if err := json.Unmarshal([]byte(script), &kakuyomuNextData); err != nil {
-		return "", nil, metadata, fmt.Errorf("kakuyomu:__NEXT_DATA__ parse error %w", err)
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "script#__NEXT_DATA__", Err: err}
 	}
 
 	if kakuyomuNextData.Props.PageProps.ApolloState == nil {
-		return "", nil, metadata, errors.New("kakuyomu:__APOLLO_STATE__ not found")
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "__APOLLO_STATE__"}
 	}
 
 	storyId := kakuyomuNextData.Query.WorkID
 
 	authorWork, ok := kakuyomuNextData.Props.PageProps.ApolloState["Work:"+storyId].(map[string]interface{})
 	if !ok {
-		return "", nil, metadata, errors.New("kakuyomu:work(author) not found")
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "__APOLLO_STATE__.Work"}
 	}
 
 	title, ok := authorWork["title"].(string)
 	if !ok {
-		return "", nil, metadata, errors.New("kakuyomu:title not found or broken type")
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "__APOLLO_STATE__.Work.title"}
 	}
 
 	updated, err := getTimFromObj(authorWork["lastEpisodePublishedAt"])
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("kakuyomu:lastEpisodePublishedAt not found or broken %w", err)
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "__APOLLO_STATE__.Work.lastEpisodePublishedAt", Err: err}
 	}
 
 	desc, ok := authorWork["introduction"].(string)
 	if !ok {
-		return "", nil, metadata, errors.New("kakuyomu:introduction not found or broken type")
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "__APOLLO_STATE__.Work.introduction"}
 	}
 
 	desc = trimDescription(desc)
 
 	authorRef, ok := authorWork["author"].(map[string]interface{})
 	if !ok {
-		return "", nil, metadata, errors.New("kakuyomu:authorRef not found or broken type")
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "__APOLLO_STATE__.Work.author"}
 	}
 	authorRefId, ok := authorRef["__ref"].(string)
 	if !ok {
-		return "", nil, metadata, errors.New("kakuyomu:authorRefId not found or broken type")
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "__APOLLO_STATE__.Work.author.__ref"}
 	}
 	authorAccount, ok := kakuyomuNextData.Props.PageProps.ApolloState[authorRefId].(map[string]interface{})
 	if !ok {
-		return "", nil, metadata, errors.New("kakuyomu:UserAccount(author) not found or broken type")
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "__APOLLO_STATE__.UserAccount"}
 	}
 	author, ok := authorAccount["activityName"].(string)
 	if !ok {
-		return "", nil, metadata, errors.New("kakuyomu:ActivityName not found or broken type")
+		return nil, metadata, &LayoutError{Site: "kakuyomu", Selector: "__APOLLO_STATE__.UserAccount.activityName"}
 	}
 
-	feed := &feeds.Feed{
+	series := &Series{
+		Site:        "kakuyomu",
+		Key:         "kakuyomu_works" + storyId,
 		Title:       title,
-		Link:        &feeds.Link{Href: fmt.Sprintf("https://kakuyomu.jp/works/%s", storyId)},
+		Link:        fmt.Sprintf("https://kakuyomu.jp/works/%s", storyId),
 		Description: desc,
-		Author:      &feeds.Author{Name: author},
+		Author:      author,
 		Updated:     updated,
 	}
 
@@ -133,25 +134,25 @@ func kakuyomuFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, Ht
 
 			uri := fmt.Sprintf("https://kakuyomu.jp/works/%s/episodes/%s", storyId, id)
 
-			feed.Items = append(feed.Items, &feeds.Item{
+			series.Episodes = append(series.Episodes, &Episode{
+				ID:      id,
 				Title:   title,
-				Link:    &feeds.Link{Href: uri},
-				Id:      id,
+				Link:    uri,
 				Created: publishedAt,
 			})
 
 		}
 	}
 
-	if len(feed.Items) == 0 {
-		return "", nil, metadata, fmt.Errorf("kakuyomu:no episode entry")
+	if len(series.Episodes) == 0 {
+		return nil, metadata, &NoEpisodesError{Site: "kakuyomu", URL: target.String()}
 	}
 
-	sort.Slice(feed.Items, func(i, j int) bool {
-		return feed.Items[i].Created.Before(feed.Items[j].Created)
+	sort.Slice(series.Episodes, func(i, j int) bool {
+		return series.Episodes[i].Created.Before(series.Episodes[j].Created)
 	})
 
-	return "kakuyomu_works" + storyId, feed, metadata, nil
+	return series, metadata, nil
 }
 
 func parseDatetimeEntity(datetime *goquery.Selection) (time.Time, error) {
@@ -168,3 +169,22 @@ func parseDatetimeEntity(datetime *goquery.Selection) (time.Time, error) {
 
 	return tm, nil
 }
+
+func kakuyomuBody(ctx context.Context, ep *Episode) (string, error) {
+	target, err := url.Parse(ep.Link)
+	if err != nil {
+		return "", fmt.Errorf("kakuyomu:%w", err)
+	}
+
+	doc, _, err := fetchDocument(withoutConditional(ctx), target)
+	if err != nil {
+		return "", fmt.Errorf("kakuyomu:FetchErr:%w", err)
+	}
+
+	body := doc.Find("div.widget-episodeBody")
+	if body.Length() == 0 {
+		return "", &LayoutError{Site: "kakuyomu", Selector: "div.widget-episodeBody"}
+	}
+
+	return sanitizeBody(body, target)
+}
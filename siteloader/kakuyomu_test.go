@@ -25,10 +25,11 @@ func TestKakuyomu(t *testing.T) {
 
 	testUrl, _ := url.Parse(testsv.URL)
 
-	fname, feed, _, err := kakuyomuFeed(context.Background(), testUrl)
+	series, _, err := kakuyomuFeed(context.Background(), testUrl)
 	assert.Nil(t, err)
+	feed := series.Feed()
 
-	assert.Equal(t, "kakuyomu_works987654321", fname)
+	assert.Equal(t, "kakuyomu_works987654321", series.Key)
 
 	assert.Equal(t, "テストタイトル", feed.Title)
 	assert.Equal(t, "https://kakuyomu.jp/works/987654321", feed.Link.Href)
@@ -79,7 +80,7 @@ func TestKakuyomu(t *testing.T) {
 
 	for index, tt := range testcases {
 		t.Run(tt.title, func(t *testing.T) {
-			assert.Equal(t, tt.id, feed.Items[index].Id)
+			assert.Equal(t, tt.id, series.Episodes[index].ID)
 			assert.Equal(t, tt.path, feed.Items[index].Link.Href)
 			assert.Equal(t, tt.title, feed.Items[index].Title)
 
@@ -0,0 +1,92 @@
+package siteloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// HostLimiter limits the rate of requests for each host.
+// It is safe for concurrent use and meant to be shared in the process.
+type HostLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu sync.Mutex
+	// theoretical arrival time of the next request for each host.
+	tat map[string]time.Time
+	// interval overridden for each host.
+	hostInterval map[string]time.Duration
+}
+
+// NewHostLimiter returns a HostLimiter which allows burst requests at once and
+// then one request per interval for each host.
+func NewHostLimiter(interval time.Duration, burst int) *HostLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &HostLimiter{
+		interval:     interval,
+		burst:        burst,
+		tat:          make(map[string]time.Time),
+		hostInterval: make(map[string]time.Duration),
+	}
+}
+
+// SetHostInterval sets the minimum interval for host if it is longer than the default.
+func (l *HostLimiter) SetHostInterval(host string, interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if interval <= l.interval {
+		delete(l.hostInterval, host)
+		return
+	}
+	l.hostInterval[host] = interval
+}
+
+// reserve reserves a request to host and returns how long to wait for it.
+func (l *HostLimiter) reserve(host string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	interval := l.interval
+	if hostInterval, ok := l.hostInterval[host]; ok {
+		interval = hostInterval
+	}
+
+	tat := l.tat[host]
+	if tat.Before(now) {
+		tat = now
+	}
+
+	allowAt := tat.Add(-time.Duration(l.burst-1) * interval)
+	l.tat[host] = tat.Add(interval)
+
+	if allowAt.Before(now) {
+		return 0
+	}
+	return allowAt.Sub(now)
+}
+
+// Wait blocks until a request to host is allowed or ctx is done.
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+
+	delay := l.reserve(host, time.Now())
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
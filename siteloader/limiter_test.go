@@ -0,0 +1,88 @@
+package siteloader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostLimiterReserve(t *testing.T) {
+	l := NewHostLimiter(time.Second, 2)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// burst
+	assert.Equal(t, time.Duration(0), l.reserve("a.example.com", now))
+	assert.Equal(t, time.Duration(0), l.reserve("a.example.com", now))
+	// then one per interval
+	assert.Equal(t, time.Second, l.reserve("a.example.com", now))
+	assert.Equal(t, 2*time.Second, l.reserve("a.example.com", now))
+
+	// other host is not affected
+	assert.Equal(t, time.Duration(0), l.reserve("b.example.com", now))
+
+	// recovered after a while
+	later := now.Add(10 * time.Second)
+	assert.Equal(t, time.Duration(0), l.reserve("a.example.com", later))
+	assert.Equal(t, time.Duration(0), l.reserve("a.example.com", later))
+	assert.Equal(t, time.Second, l.reserve("a.example.com", later))
+}
+
+func TestHostLimiterHostInterval(t *testing.T) {
+	l := NewHostLimiter(time.Second, 1)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l.SetHostInterval("a.example.com", 5*time.Second)
+	// shorter than default is ignored
+	l.SetHostInterval("b.example.com", time.Millisecond)
+
+	assert.Equal(t, time.Duration(0), l.reserve("a.example.com", now))
+	assert.Equal(t, 5*time.Second, l.reserve("a.example.com", now))
+	assert.Equal(t, time.Duration(0), l.reserve("b.example.com", now))
+	assert.Equal(t, time.Second, l.reserve("b.example.com", now))
+}
+
+func TestHostLimiterWait(t *testing.T) {
+	l := NewHostLimiter(50*time.Millisecond, 1)
+
+	started := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, l.Wait(context.Background(), "example.com"))
+	}
+	assert.True(t, time.Since(started) >= 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l = NewHostLimiter(time.Hour, 1)
+	assert.Nil(t, l.Wait(ctx, "example.com"))
+	assert.ErrorIs(t, l.Wait(ctx, "example.com"), context.Canceled)
+
+	var nilLimiter *HostLimiter
+	assert.Nil(t, nilLimiter.Wait(ctx, "example.com"))
+}
+
+func TestClientLimiter(t *testing.T) {
+	count := 0
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		fmt.Fprint(w, "example")
+	}))
+	defer testsv.Close()
+
+	testUrl, _ := url.Parse(testsv.URL)
+	ctx := SetClient(context.Background(), &Client{Limiter: NewHostLimiter(50*time.Millisecond, 1)})
+
+	started := time.Now()
+	for i := 0; i < 3; i++ {
+		_, _, err := fetchDocument(ctx, testUrl)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, 3, count)
+	assert.True(t, time.Since(started) >= 100*time.Millisecond)
+}
@@ -0,0 +1,34 @@
+package siteloader
+
+import (
+	"context"
+	"log/slog"
+)
+
+const loggerKey = loggerType("Logger")
+
+type loggerType string
+
+// SetLogger returns the context which makes the library log to l.
+// slog.Default() is used if no logger is set.
+//
+// Logs carry the fields site, target, url, status, bytes, duration,
+// error and error_class where applicable.
+func SetLogger(ctx context.Context, l *slog.Logger) context.Context {
+	if l == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// WithLogger makes the call log to l. See SetLogger.
+func WithLogger(l *slog.Logger) Option {
+	return Option{apply: func(o *callOptions) { o.logger = l }}
+}
+
+func getLogger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
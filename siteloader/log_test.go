@@ -0,0 +1,81 @@
+package siteloader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readLogs(t *testing.T, buf *bytes.Buffer) map[string]map[string]any {
+	t.Helper()
+
+	logs := map[string]map[string]any{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid log:%v", err)
+		}
+		logs[record["msg"].(string)] = record
+	}
+	return logs
+}
+
+func TestLogger(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "example")
+	}))
+	defer testsv.Close()
+
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", testsv.URL, func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		if _, _, err := fetchDocument(ctx, target); err != nil {
+			return nil, HttpMetadata{}, err
+		}
+		if target.Path == "/broken" {
+			return nil, HttpMetadata{}, &LayoutError{Site: "test", Selector: "h2"}
+		}
+		return &Series{Key: "test", Episodes: []*Episode{{ID: "ep1"}}}, HttpMetadata{}, nil
+	})))
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	_, _, err := r.GetSeriesWithOptions(context.Background(), testsv.URL+"/1", WithLogger(logger))
+	assert.Nil(t, err)
+
+	logs := readLogs(t, &buf)
+
+	fetched := logs["fetched"]
+	assert.Equal(t, "test", fetched["site"])
+	assert.Equal(t, testsv.URL+"/1", fetched["target"])
+	assert.Equal(t, testsv.URL+"/1", fetched["url"])
+	assert.Equal(t, float64(200), fetched["status"])
+	assert.Equal(t, float64(7), fetched["bytes"])
+	assert.Contains(t, fetched, "duration")
+
+	assert.Equal(t, "header", logs["charset"]["charset_source"])
+
+	loaded := logs["loaded"]
+	assert.Equal(t, "test", loaded["site"])
+	assert.Equal(t, float64(1), loaded["episodes"])
+
+	// error
+	_, _, err = r.GetSeries(SetLogger(context.Background(), logger), testsv.URL+"/broken")
+	assert.Error(t, err)
+
+	failed := readLogs(t, &buf)["load failed"]
+	assert.Equal(t, "test", failed["site"])
+	assert.Equal(t, "layout", failed["error_class"])
+	assert.Equal(t, "test:h2 not found", failed["error"])
+}
@@ -0,0 +1,91 @@
+package siteloader
+
+import (
+	"context"
+	"mime"
+	"net/http"
+)
+
+type probeMediaType string
+
+const probeMediaKey = probeMediaType("probeMedia")
+
+// SetProbeMedia makes the registry send HEAD requests for thumbnails whose
+// media type is unknown from the extension, to render it in enclosures.
+func SetProbeMedia(ctx context.Context, probe bool) context.Context {
+	return context.WithValue(ctx, probeMediaKey, probe)
+}
+
+// WithProbeMedia probes thumbnails for the call. See SetProbeMedia.
+func WithProbeMedia() Option {
+	return Option{apply: func(o *callOptions) { o.probeMedia = true }}
+}
+
+func getProbeMedia(ctx context.Context) bool {
+	if getCallOptions(ctx).probeMedia {
+		return true
+	}
+	probe, _ := ctx.Value(probeMediaKey).(bool)
+	return probe
+}
+
+type mediaInfo struct {
+	mediaType string
+	length    int64
+}
+
+// probeThumbnails sets the media type and the length of thumbnails from HEAD responses.
+// Failures are only logged not to fail the feed.
+func probeThumbnails(ctx context.Context, series *Series) {
+	probed := map[string]*mediaInfo{}
+	for _, ep := range series.Episodes {
+		if ep.Thumbnail == "" || ep.thumbnailType() != "" {
+			continue
+		}
+
+		info, ok := probed[ep.Thumbnail]
+		if !ok {
+			info = probeMedia(ctx, ep.Thumbnail)
+			probed[ep.Thumbnail] = info
+		}
+		if info != nil {
+			ep.ThumbnailType = info.mediaType
+			ep.ThumbnailLength = info.length
+		}
+	}
+}
+
+// probeMedia returns the media type and the length of uri. nil if unknown.
+func probeMedia(ctx context.Context, uri string) *mediaInfo {
+	logger := getLogger(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, uri, nil)
+	if err != nil {
+		logger.Debug("cannot probe media", "url", uri, "error", err)
+		return nil
+	}
+
+	res, err := getClient(ctx).Do(req)
+	if err != nil {
+		logger.Debug("cannot probe media", "url", uri, "error", err)
+		return nil
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res); err != nil {
+		logger.Debug("cannot probe media", "url", uri, "error", err)
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		logger.Debug("cannot probe media", "url", uri, "error", err)
+		return nil
+	}
+
+	info := &mediaInfo{mediaType: mediaType}
+	if res.ContentLength > 0 {
+		info.length = res.ContentLength
+	}
+	return info
+}
@@ -0,0 +1,70 @@
+package siteloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeMedia(t *testing.T) {
+	var heads atomic.Int32
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+		}
+		switch r.URL.Path {
+		case "/img/1":
+			w.Header().Set("Content-Type", "image/webp")
+			w.Header().Set("Content-Length", "1234")
+		case "/img/missing":
+			http.NotFound(w, r)
+		}
+	}))
+	defer testsv.Close()
+
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", testsv.URL, func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		return &Series{Key: "test", Episodes: []*Episode{
+			{ID: "1", Thumbnail: testsv.URL + "/img/1"},
+			{ID: "2", Thumbnail: testsv.URL + "/img/2.png"},
+			{ID: "3", Thumbnail: testsv.URL + "/img/missing"},
+			{ID: "4", Thumbnail: testsv.URL + "/img/1"},
+			{ID: "5"},
+		}}, HttpMetadata{}, nil
+	})))
+
+	// not probed by default
+	series, _, err := r.GetSeries(context.Background(), testsv.URL+"/series")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), heads.Load())
+	assert.Equal(t, "", series.Episodes[0].thumbnailType())
+
+	series, _, err = r.GetSeriesWithOptions(context.Background(), testsv.URL+"/series", WithProbeMedia())
+	assert.Nil(t, err)
+	// once for each unknown thumbnail
+	assert.Equal(t, int32(2), heads.Load())
+
+	assert.Equal(t, "image/webp", series.Episodes[0].ThumbnailType)
+	assert.Equal(t, int64(1234), series.Episodes[0].ThumbnailLength)
+	assert.Equal(t, "", series.Episodes[1].ThumbnailType)
+	assert.Equal(t, "image/png", series.Episodes[1].thumbnailType())
+	assert.Equal(t, "", series.Episodes[2].thumbnailType())
+	assert.Equal(t, "image/webp", series.Episodes[3].ThumbnailType)
+
+	feed := series.Feed()
+	assert.Equal(t, "image/webp", feed.Items[0].Enclosure.Type)
+	assert.Equal(t, "1234", feed.Items[0].Enclosure.Length)
+	assert.Equal(t, "image/png", feed.Items[1].Enclosure.Type)
+	assert.Equal(t, "", feed.Items[1].Enclosure.Length)
+	assert.Nil(t, feed.Items[4].Enclosure)
+
+	// by context
+	_, _, err = r.GetSeries(SetProbeMedia(context.Background(), true), testsv.URL+"/series")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), heads.Load())
+}
@@ -5,38 +5,37 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/gorilla/feeds"
 )
 
-func meteorFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMetadata, error) {
+func meteorFeed(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
 	doc, metadata, err := fetchDocument(ctx, target)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("meteor:FetchErr:%w", err)
+		return nil, metadata, fmt.Errorf("meteor:FetchErr:%w", err)
 	}
 
 	title := strings.TrimSpace(doc.Find("body > main > h2").Text())
 	if title == "" {
-		return "", nil, metadata, fmt.Errorf("meteor:title not found")
+		return nil, metadata, &LayoutError{Site: "meteor", Selector: "body > main > h2"}
 	}
 	author := getTrimmedAuthor(doc.Find("body > main > div.content-container > div.group-button-r2.mt-4.mb-5 > a").Text())
 	if author == "" {
-		return "", nil, metadata, fmt.Errorf("meteor:author not found")
+		return nil, metadata, &LayoutError{Site: "meteor", Selector: "body > main > div.content-container > div.group-button-r2.mt-4.mb-5 > a"}
 	}
 
 	desc := trimDescription(doc.Find("body > main > div.content-container > div.link-color.lh-lg.mx-2.mx-lg-0").Text())
 	if desc == "" {
-		return "", nil, metadata, fmt.Errorf("meteor:desc not found")
+		return nil, metadata, &LayoutError{Site: "meteor", Selector: "body > main > div.content-container > div.link-color.lh-lg.mx-2.mx-lg-0"}
 	}
 
-	feed := &feeds.Feed{
+	series := &Series{
+		Site:        "meteor",
+		Key:         "meteor_" + escapePath(target.Path),
 		Title:       title,
-		Link:        &feeds.Link{Href: target.String()},
+		Link:        target.String(),
 		Description: desc,
-		Author:      &feeds.Author{Name: author},
-		Created:     time.Now(),
+		Author:      author,
 	}
 
 	episodes := doc.Find("div.episode-item")
@@ -45,19 +44,19 @@ func meteorFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, Http
 		title := strings.TrimSpace(s.Find("div.fw-bold").Text())
 		uri, exist := s.Find("a").Attr("href")
 		if exist {
-			feed.Items = append(feed.Items, &feeds.Item{
+			series.Episodes = append(series.Episodes, &Episode{
+				ID:    generateHashedHex(uri),
 				Title: title,
-				Link:  &feeds.Link{Href: uri},
-				Id:    generateHashedHex(uri),
+				Link:  uri,
 			})
 		}
 	})
 
-	if len(feed.Items) == 0 {
-		return "", nil, metadata, fmt.Errorf("meteor:no episode entry")
+	if len(series.Episodes) == 0 {
+		return nil, metadata, &NoEpisodesError{Site: "meteor", URL: target.String()}
 	}
 
-	return "meteor_" + escapePath(target.Path), feed, metadata, nil
+	return series, metadata, nil
 }
 
 func getTrimmedAuthor(author string) string {
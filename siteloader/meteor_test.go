@@ -27,11 +27,10 @@ func TestMeteorErr(t *testing.T) {
 
 	testUrl, _ := url.Parse(testsv.URL)
 
-	fname, doc, _, err := meteorFeed(context.Background(), testUrl)
+	series, _, err := meteorFeed(context.Background(), testUrl)
 
 	assert.Error(t, err)
-	assert.Equal(t, "", fname)
-	assert.Nil(t, doc)
+	assert.Nil(t, series)
 }
 
 func TestMeteor(t *testing.T) {
@@ -49,10 +48,11 @@ func TestMeteor(t *testing.T) {
 
 	testUrl, _ := url.Parse(testsv.URL + "/path_t/est")
 
-	fname, feed, _, err := meteorFeed(context.Background(), testUrl)
+	series, _, err := meteorFeed(context.Background(), testUrl)
 	assert.Nil(t, err)
+	feed := series.Feed()
 
-	assert.Equal(t, "meteor_path_test", fname)
+	assert.Equal(t, "meteor_path_test", series.Key)
 
 	assert.Equal(t, "テストタイトル", feed.Title)
 	assert.Equal(t, testUrl.String(), feed.Link.Href)
@@ -88,7 +88,7 @@ func TestMeteor(t *testing.T) {
 
 	for index, tt := range testcases {
 		t.Run(tt.title, func(t *testing.T) {
-			assert.Equal(t, tt.hash, feed.Items[index].Id)
+			assert.Equal(t, tt.hash, series.Episodes[index].ID)
 			assert.Equal(t, tt.path, feed.Items[index].Link.Href)
 			assert.Equal(t, tt.title, feed.Items[index].Title)
 		})
@@ -0,0 +1,225 @@
+package siteloader
+
+import (
+	"context"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/feeds"
+)
+
+// Pricing describes whether an episode can be read for free.
+type Pricing int
+
+const (
+	// PricingUnknown means the site does not tell.
+	PricingUnknown Pricing = iota
+	// PricingFree means the episode is free to read.
+	PricingFree
+	// PricingPaid means the episode requires payment.
+	PricingPaid
+)
+
+func (p Pricing) String() string {
+	switch p {
+	case PricingFree:
+		return "free"
+	case PricingPaid:
+		return "paid"
+	default:
+		return "unknown"
+	}
+}
+
+// Series is a work(comic or novel) and its episodes loaded from a site.
+type Series struct {
+	// Site is the name of the loader (e.g. "meteor").
+	Site string
+	// Key is unique among all series and safe as a file name.
+	Key string
+	// ID is the identifier of the series in the site. empty if the site has none.
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Author      string
+	Thumbnail   string
+	Completed   bool
+	Created     time.Time
+	Updated     time.Time
+	Episodes    []*Episode
+}
+
+// Episode is an episode(chapter) of a Series.
+type Episode struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	// Chapter is the name of the chapter group the episode belongs to.
+	Chapter string
+	// Number is the episode number. zero if unknown.
+	Number    int
+	Thumbnail string
+	// ThumbnailType is the media type of the thumbnail. guessed from the extension if empty.
+	ThumbnailType string
+	// ThumbnailLength is the size of the thumbnail in bytes. zero if unknown.
+	ThumbnailLength int64
+	Pricing         Pricing
+	// FreeUntil is the end of the free period. zero if unknown.
+	FreeUntil time.Time
+	Created   time.Time
+	Updated   time.Time
+	// Unavailable means the episode is kept by Archive after the site removed it.
+	Unavailable bool
+	// Content is the body as sanitized HTML. empty unless loaded by SetFullText.
+	Content string
+}
+
+// FullTitle returns the title prefixed with the chapter name.
+func (e *Episode) FullTitle() string {
+	if e.Chapter == "" {
+		return e.Title
+	}
+	return e.Chapter + "/" + e.Title
+}
+
+// entryDescription returns the description of the feed entry, marked if the site removed the episode.
+func (e *Episode) entryDescription() string {
+	if e.Unavailable {
+		return "(公開終了) " + e.Description
+	}
+	return e.Description
+}
+
+// tagPrefix is the prefix of RFC 4151 tag: URIs for feed and entry IDs.
+const tagPrefix = "tag:3pf.jp,2024:comic2atom/"
+
+// TagURI returns the tag: URI of the series, which is stable and globally unique.
+func (s *Series) TagURI() string {
+	return tagPrefix + s.Key
+}
+
+// EpisodeTagURI returns the tag: URI of ep in the series.
+func (s *Series) EpisodeTagURI(ep *Episode) string {
+	return s.TagURI() + "/" + url.PathEscape(episodeKey(ep))
+}
+
+// episodeKey returns the ID of ep, or the hash of the link if the site has no ID.
+func episodeKey(ep *Episode) string {
+	if ep.ID == "" {
+		return generateHashedHex(ep.Link)
+	}
+	return ep.ID
+}
+
+// latestTime returns the latest time of the series and its episodes.
+func (s *Series) latestTime() time.Time {
+	latest := s.Updated
+	for _, ep := range s.Episodes {
+		for _, t := range []time.Time{ep.Created, ep.Updated} {
+			if t.After(latest) {
+				latest = t
+			}
+		}
+	}
+	return latest
+}
+
+// Feed renders the series as a gorilla/feeds Feed. IDs are tag: URIs.
+func (s *Series) Feed() *feeds.Feed {
+	return s.feed(false)
+}
+
+// LegacyFeed renders the series as Feed does, but with the IDs of former versions
+// (link of the series and the ID from each site) not to re-flood readers.
+func (s *Series) LegacyFeed() *feeds.Feed {
+	return s.feed(true)
+}
+
+func (s *Series) feed(legacy bool) *feeds.Feed {
+	feed := &feeds.Feed{
+		Title:       s.Title,
+		Link:        &feeds.Link{Href: s.Link},
+		Description: s.Description,
+		Author:      &feeds.Author{Name: s.Author},
+		Created:     s.Created,
+		Updated:     s.Updated,
+		Id:          s.TagURI(),
+	}
+	if feed.Created.IsZero() && feed.Updated.IsZero() {
+		// the site publishes no dates.
+		feed.Updated = s.latestTime()
+		if feed.Updated.IsZero() {
+			feed.Updated = time.Now()
+		}
+	}
+	if legacy {
+		// gorilla/feeds used the link as the ID of Atom feed.
+		feed.Id = s.Link
+	}
+	if s.Thumbnail != "" {
+		feed.Image = &feeds.Image{Url: s.Thumbnail, Title: s.Title, Link: s.Link}
+	}
+
+	for _, ep := range s.Episodes {
+		item := &feeds.Item{
+			Title:       ep.FullTitle(),
+			Link:        &feeds.Link{Href: ep.Link},
+			Description: ep.entryDescription(),
+			Content:     ep.Content,
+			Id:          s.EpisodeTagURI(ep),
+			// the IDs are not URLs to read the episode.
+			IsPermaLink: "false",
+			Created:     ep.Created,
+			Updated:     ep.Updated,
+		}
+		if legacy {
+			item.Id = ep.ID
+		}
+		if ep.Thumbnail != "" {
+			item.Enclosure = &feeds.Enclosure{Url: ep.Thumbnail, Type: ep.thumbnailType()}
+			if ep.ThumbnailLength > 0 {
+				item.Enclosure.Length = strconv.FormatInt(ep.ThumbnailLength, 10)
+			}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed
+}
+
+// thumbnailType returns the media type of the thumbnail. empty if unknown.
+func (e *Episode) thumbnailType() string {
+	if e.ThumbnailType != "" {
+		return e.ThumbnailType
+	}
+	return guessMediaType(e.Thumbnail)
+}
+
+// guessMediaType returns the media type from the extension of uri. empty if unknown.
+func guessMediaType(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(mime.TypeByExtension(path.Ext(u.Path)))
+	return mediaType
+}
+
+type legacyIDsType string
+
+const legacyIDsKey = legacyIDsType("legacyIDs")
+
+// SetLegacyIDs makes GetFeed render the IDs of former versions (see LegacyFeed).
+func SetLegacyIDs(ctx context.Context, legacy bool) context.Context {
+	return context.WithValue(ctx, legacyIDsKey, legacy)
+}
+
+func getLegacyIDs(ctx context.Context) bool {
+	legacy, _ := ctx.Value(legacyIDsKey).(bool)
+	return legacy
+}
@@ -0,0 +1,114 @@
+package siteloader
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesFeed(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	series := &Series{
+		Site:        "test",
+		Key:         "test_1",
+		ID:          "feedid",
+		Title:       "テストタイトル",
+		Link:        "https://example.com/1",
+		Description: "テストてすとストーリー",
+		Author:      "テスト著者",
+		Created:     created,
+		Episodes: []*Episode{
+			{
+				ID:        "ep1",
+				Title:     "サブタイトル1",
+				Link:      "https://example.com/1/1",
+				Chapter:   "チャプター1",
+				Thumbnail: "https://example.com/1/1.jpg",
+				Pricing:   PricingFree,
+				Created:   created,
+			},
+			{
+				ID:    "ep2",
+				Title: "サブタイトル2",
+				Link:  "https://example.com/1/2",
+			},
+		},
+	}
+
+	feed := series.Feed()
+	assert.Equal(t, "テストタイトル", feed.Title)
+	assert.Equal(t, "https://example.com/1", feed.Link.Href)
+	assert.Equal(t, "テストてすとストーリー", feed.Description)
+	assert.Equal(t, "テスト著者", feed.Author.Name)
+	assert.Equal(t, "tag:3pf.jp,2024:comic2atom/test_1", feed.Id)
+	assert.True(t, created.Equal(feed.Created))
+
+	assert.Equal(t, 2, len(feed.Items))
+	assert.Equal(t, "tag:3pf.jp,2024:comic2atom/test_1/ep1", feed.Items[0].Id)
+	assert.Equal(t, "チャプター1/サブタイトル1", feed.Items[0].Title)
+	assert.Equal(t, "https://example.com/1/1", feed.Items[0].Link.Href)
+	assert.Equal(t, "https://example.com/1/1.jpg", feed.Items[0].Enclosure.Url)
+	assert.Equal(t, "サブタイトル2", feed.Items[1].Title)
+	assert.Nil(t, feed.Items[1].Enclosure)
+}
+
+func TestSeriesLegacyFeed(t *testing.T) {
+	series := &Series{
+		Key:  "test_1",
+		ID:   "feedid",
+		Link: "https://example.com/1",
+		Episodes: []*Episode{
+			{ID: "ep1", Link: "https://example.com/1/1"},
+			{ID: "ep 2/x", Link: "https://example.com/1/2"},
+			{Link: "https://example.com/1/3"},
+		},
+	}
+
+	feed := series.Feed()
+	assert.Equal(t, "tag:3pf.jp,2024:comic2atom/test_1/ep%202%2Fx", feed.Items[1].Id)
+	assert.Equal(t, "tag:3pf.jp,2024:comic2atom/test_1/"+generateHashedHex("https://example.com/1/3"), feed.Items[2].Id)
+
+	atom, err := ToAtom(feed)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(atom, "<id>tag:3pf.jp,2024:comic2atom/test_1</id>"))
+	assert.True(t, strings.Contains(atom, "<id>tag:3pf.jp,2024:comic2atom/test_1/ep1</id>"))
+
+	// gorilla/feeds generates random IDs for items without ID and date
+	series.Episodes = series.Episodes[:2]
+	legacy := series.LegacyFeed()
+	assert.Equal(t, "ep1", legacy.Items[0].Id)
+
+	// same as feeds.Feed.ToAtom
+	atom, err = ToAtom(legacy)
+	assert.Nil(t, err)
+	want, err := legacy.ToAtom()
+	assert.Nil(t, err)
+	assert.Equal(t, want, atom)
+}
+
+func TestGetFeedLegacyIDs(t *testing.T) {
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", "https://example.com/", func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		return &Series{Key: "test_1", Link: target.String(), Episodes: []*Episode{{ID: "ep1"}}}, HttpMetadata{}, nil
+	})))
+
+	_, feed, _, err := r.GetFeed(context.Background(), "https://example.com/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "tag:3pf.jp,2024:comic2atom/test_1/ep1", feed.Items[0].Id)
+
+	_, feed, _, err = r.GetFeed(SetLegacyIDs(context.Background(), true), "https://example.com/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/1", feed.Id)
+	assert.Equal(t, "ep1", feed.Items[0].Id)
+}
+
+func TestPricingString(t *testing.T) {
+	assert.Equal(t, "unknown", PricingUnknown.String())
+	assert.Equal(t, "free", PricingFree.String())
+	assert.Equal(t, "paid", PricingPaid.String())
+}
@@ -8,35 +8,45 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/gorilla/feeds"
 )
 
-func narouFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMetadata, error) {
+func narouFeed(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+	// validators of each page do not cover the whole episode list across pages.
+	// they are computed from the content by the registry instead.
+	ctx = withoutConditional(ctx)
+
+	loc, err := siteLocation(ctx)
+	if err != nil {
+		return nil, HttpMetadata{}, fmt.Errorf("narou:%w", err)
+	}
+
 	doc, metadata, err := fetchDocument(ctx, target)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("storia:FetchErr:%w", err)
+		return nil, metadata, fmt.Errorf("narou:FetchErr:%w", err)
 	}
 
 	title := doc.Find("h1.p-novel__title").Text()
 	if title == "" {
-		return "", nil, metadata, fmt.Errorf("narou:title not found")
+		return nil, metadata, &LayoutError{Site: "narou", Selector: "h1.p-novel__title"}
 	}
 
 	author := doc.Find("div.p-novel__author > a").Text()
 	if author == "" {
-		return "", nil, metadata, fmt.Errorf("narou:author not found")
+		return nil, metadata, &LayoutError{Site: "narou", Selector: "div.p-novel__author > a"}
 	}
 
 	desc := doc.Find("div.p-novel__summary").Text()
 	if desc == "" {
-		return "", nil, metadata, fmt.Errorf("narou:description not found")
+		return nil, metadata, &LayoutError{Site: "narou", Selector: "div.p-novel__summary"}
 	}
 
-	feed := &feeds.Feed{
+	series := &Series{
+		Site:        "narou",
+		Key:         "narou_" + escapePath(target.Path),
 		Title:       title,
-		Link:        &feeds.Link{Href: target.String()},
+		Link:        target.String(),
 		Description: trimDescription(desc),
-		Author:      &feeds.Author{Name: author},
+		Author:      author,
 	}
 
 	chapter := ""
@@ -54,55 +64,51 @@ func narouFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpM
 				subtitle := trimDescription(subject.Text())
 				link, ok := subject.Attr("href")
 				if !ok {
-					eachError = errors.New("cannot find href")
+					eachError = &LayoutError{Site: "narou", Selector: "a.p-eplist__subtitle[href]"}
 					return false
 				}
 				href, err := resolveRelativeURI(target, link)
 				if err != nil {
-					eachError = fmt.Errorf("cannot parse URL:%w", err)
+					eachError = &LayoutError{Site: "narou", Selector: "a.p-eplist__subtitle[href]", Err: err}
 					return false
 				}
 
-				fulltitle := subtitle
-				if chapter != "" {
-					fulltitle = chapter + "/" + subtitle
-				}
-
-				it := &feeds.Item{
-					Title: fulltitle,
-					Link:  &feeds.Link{Href: href},
-					Id:    generateHashedHex(href),
+				it := &Episode{
+					ID:      generateHashedHex(href),
+					Title:   subtitle,
+					Link:    href,
+					Chapter: chapter,
 				}
 
 				created := s.Find("div.p-eplist__update").Text()
 				if created == "" {
-					eachError = errors.New("cannot find created timestamp")
+					eachError = &LayoutError{Site: "narou", Selector: "div.p-eplist__update"}
 					return false
 				}
-				parsed, err := parseTimestamp(created)
+				parsed, err := parseTimestamp(created, loc)
 				if err != nil {
-					eachError = fmt.Errorf("cannot parse created[%s]:%w", created, err)
+					eachError = &LayoutError{Site: "narou", Selector: "div.p-eplist__update", Err: fmt.Errorf("cannot parse created[%s]:%w", created, err)}
 					return false
 				}
 				it.Created = parsed
-				if parsed.After(feed.Updated) {
-					feed.Updated = parsed
+				if parsed.After(series.Updated) {
+					series.Updated = parsed
 				}
 
 				updated, ok := s.Find("div.p-eplist__update > span").Attr("title")
 				if ok {
-					parsed, err := parseTimestamp(updated)
+					parsed, err := parseTimestamp(updated, loc)
 					if err != nil {
-						eachError = fmt.Errorf("cannot parse updated[%s]:%w", updated, err)
+						eachError = &LayoutError{Site: "narou", Selector: "div.p-eplist__update > span[title]", Err: fmt.Errorf("cannot parse updated[%s]:%w", updated, err)}
 						return false
 					}
 					it.Updated = parsed
-					if parsed.After(feed.Updated) {
-						feed.Updated = parsed
+					if parsed.After(series.Updated) {
+						series.Updated = parsed
 					}
 				}
 
-				feed.Items = append(feed.Items, it)
+				series.Episodes = append(series.Episodes, it)
 			}
 
 			return true
@@ -120,28 +126,27 @@ func narouFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpM
 
 		nextURL, err := target.Parse(next)
 		if err != nil {
-			return "", nil, metadata, fmt.Errorf("narou:cannot parse next URL:%w", err)
+			return nil, metadata, &LayoutError{Site: "narou", Selector: "a.c-pager__item--next[href]", Err: err}
 		}
 
-		// use latest metadata(etag and last-modified) for next request
 		doc, metadata, err = fetchDocument(ctx, nextURL)
 		if err != nil {
-			return "", nil, metadata, fmt.Errorf("narou:Fetch(Next)Err:%w", err)
+			return nil, metadata, fmt.Errorf("narou:Fetch(Next)Err:%w", err)
 		}
 	}
 
 	if eachError != nil {
-		return "", nil, metadata, fmt.Errorf("narou:%w", eachError)
+		return nil, metadata, eachError
 	}
 
-	if len(feed.Items) == 0 {
-		return "", nil, metadata, fmt.Errorf("narou:no episode entry")
+	if len(series.Episodes) == 0 {
+		return nil, metadata, &NoEpisodesError{Site: "narou", URL: target.String()}
 	}
 
-	return "narou_" + escapePath(target.Path), feed, metadata, nil
+	return series, HttpMetadata{}, nil
 }
 
-func parseTimestamp(str string) (time.Time, error) {
+func parseTimestamp(str string, loc *time.Location) (time.Time, error) {
 	cleanup := trimDescription(str)
 	// 1234567890123456
 	// 2006/01/02 15:04
@@ -150,10 +155,24 @@ func parseTimestamp(str string) (time.Time, error) {
 	}
 	filtered := cleanup[:16]
 
-	loc, err := time.LoadLocation("Asia/Tokyo")
+	return time.ParseInLocation("2006/01/02 15:04", filtered, loc)
+}
+
+func narouBody(ctx context.Context, ep *Episode) (string, error) {
+	target, err := url.Parse(ep.Link)
 	if err != nil {
-		return time.Time{}, err
+		return "", fmt.Errorf("narou:%w", err)
 	}
 
-	return time.ParseInLocation("2006/01/02 15:04", filtered, loc)
+	doc, _, err := fetchDocument(withoutConditional(ctx), target)
+	if err != nil {
+		return "", fmt.Errorf("narou:FetchErr:%w", err)
+	}
+
+	body := doc.Find("div.p-novel__body")
+	if body.Length() == 0 {
+		return "", &LayoutError{Site: "narou", Selector: "div.p-novel__body"}
+	}
+
+	return sanitizeBody(body, target)
 }
@@ -15,6 +15,8 @@ import (
 )
 
 func TestNarou(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+
 	var exampleHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		fn := "./testdata/narou_test_p1.html"
@@ -36,49 +38,48 @@ func TestNarou(t *testing.T) {
 
 	testUrl, _ := url.Parse(testsv.URL + "/path_t/est")
 
-	fname, feed, _, err := narouFeed(context.Background(), testUrl)
+	series, _, err := narouFeed(context.Background(), testUrl)
 	assert.Nil(t, err)
+	feed := series.Feed()
 
-	assert.Equal(t, "narou_path_test", fname)
+	assert.Equal(t, "narou_path_test", series.Key)
 
 	assert.Equal(t, "テストタイトル", feed.Title)
 	assert.Equal(t, testUrl.String(), feed.Link.Href)
 	assert.Equal(t, "テスト著者", feed.Author.Name)
 	assert.Equal(t, "テストてすとストーリー", feed.Description)
 
-	feedWantUpdated := parseTestDate(t, "2022-05-28 11:12:00 (JST)")
+	feedWantUpdated := time.Date(2022, 5, 28, 11, 12, 0, 0, jst)
 	assert.True(t, feedWantUpdated.Equal(feed.Updated),
 		"(feed updated)want %v,got %v", feedWantUpdated, feed.Updated)
 
 	testcases := []struct {
 		path    string
 		title   string
-		created string
-		updated string
+		created time.Time
+		updated time.Time
 	}{
 		{
 			path:    "/novelid/1/",
 			title:   "チャプター1/サブタイトル1",
-			created: "2022-05-26 18:00:00 (JST)",
-			updated: "2022-05-27 18:41:00 (JST)",
+			created: time.Date(2022, 5, 26, 18, 0, 0, 0, jst),
+			updated: time.Date(2022, 5, 27, 18, 41, 0, 0, jst),
 		},
 		{
 			path:    "/novelid/2/",
 			title:   "チャプター1/サブタイトル2",
-			created: "2022-05-26 19:00:00 (JST)",
-			updated: "",
+			created: time.Date(2022, 5, 26, 19, 0, 0, 0, jst),
 		},
 		{
 			path:    "/novelid/3/",
 			title:   "チャプター2/サブタイトル3",
-			created: "2022-05-27 16:00:00 (JST)",
-			updated: "2022-05-28 11:12:00 (JST)",
+			created: time.Date(2022, 5, 27, 16, 0, 0, 0, jst),
+			updated: time.Date(2022, 5, 28, 11, 12, 0, 0, jst),
 		},
 		{
 			path:    "/novelid/4/",
 			title:   "チャプター2/サブタイトル4",
-			created: "2022-05-27 20:00:00 (JST)",
-			updated: "",
+			created: time.Date(2022, 5, 27, 20, 0, 0, 0, jst),
 		},
 	}
 
@@ -86,45 +87,44 @@ func TestNarou(t *testing.T) {
 		t.Run(tt.title, func(t *testing.T) {
 			abspath, _ := resolveRelativeURI(testUrl, tt.path)
 
-			assert.Equal(t, generateHashedHex(abspath), feed.Items[index].Id)
+			assert.Equal(t, generateHashedHex(abspath), series.Episodes[index].ID)
 			assert.Equal(t, abspath, feed.Items[index].Link.Href)
 			assert.Equal(t, tt.title, feed.Items[index].Title)
 
-			wantTime := parseTestDate(t, tt.created)
-			assert.True(t, wantTime.Equal(feed.Items[index].Created),
-				"(created)want %v,got %v", wantTime, feed.Items[index].Created)
-
-			wantTime = parseTestDate(t, tt.updated)
-			assert.True(t, wantTime.Equal(feed.Items[index].Updated),
-				"(updated)want %v,got %v", wantTime, feed.Items[index].Updated)
+			assert.True(t, tt.created.Equal(feed.Items[index].Created),
+				"(created)want %v,got %v", tt.created, feed.Items[index].Created)
+			assert.True(t, tt.updated.Equal(feed.Items[index].Updated),
+				"(updated)want %v,got %v", tt.updated, feed.Items[index].Updated)
 		})
 	}
 	assert.Panics(t, func() { _ = feed.Items[4].Title })
 }
 
 func Test_parseTimestamp(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+
 	tests := []struct {
 		name    string
 		arg     string
-		want    string
+		want    time.Time
 		wantErr bool
 	}{
 		{
 			name:    "SUCCESS(with update)",
 			arg:     "2022/05/27 18:41 改稿",
-			want:    "2022-05-27 18:41:00 (JST)",
+			want:    time.Date(2022, 5, 27, 18, 41, 0, 0, jst),
 			wantErr: false,
 		},
 		{
 			name:    "SUCCESS(with update 2)",
 			arg:     "2022/05/27 19:00 改",
-			want:    "2022-05-27 19:00:00 (JST)",
+			want:    time.Date(2022, 5, 27, 19, 0, 0, 0, jst),
 			wantErr: false,
 		},
 		{
 			name:    "SUCCESS(simple)",
 			arg:     "2022/05/27 19:00",
-			want:    "2022-05-27 19:00:00 (JST)",
+			want:    time.Date(2022, 5, 27, 19, 0, 0, 0, jst),
 			wantErr: false,
 		},
 		{
@@ -143,18 +143,22 @@ func Test_parseTimestamp(t *testing.T) {
 			wantErr: true,
 		},
 	}
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseTimestamp(tt.arg)
+			got, err := parseTimestamp(tt.arg, loc)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseTimestamp() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
 			if !tt.wantErr {
-				wantTime := parseTestDate(t, tt.want)
-				if !got.Equal(wantTime) {
-					t.Errorf("parseTimestamp() = %v, want %v", got, wantTime)
+				if !got.Equal(tt.want) {
+					t.Errorf("parseTimestamp() = %v, want %v", got, tt.want)
 				}
 			}
 		})
@@ -0,0 +1,176 @@
+package siteloader
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// SiteOption is the name of an option which only some sites support.
+// Loaders declare supported ones with NewPrefixLoader.
+type SiteOption string
+
+const (
+	// SiteOptionFreeOnly is set by WithFreeOnly.
+	// The site tells whether each episode is free to read.
+	SiteOptionFreeOnly SiteOption = "free-only"
+	// SiteOptionPaid is set by WithPaid.
+	// The site outputs only free episodes unless WithPaid is set.
+	SiteOptionPaid SiteOption = "paid"
+	// SiteOptionLocation is set by WithLocation.
+	// The site publishes dates without the timezone.
+	SiteOptionLocation SiteOption = "location"
+	// SiteOptionFullText is set by WithFullText.
+	// The site publishes the body of episodes as HTML. (e.g. novels)
+	SiteOptionFullText SiteOption = "full-text"
+)
+
+type callOptions struct {
+	ifNoneMatch     string
+	ifModifiedSince string
+	client          *Client
+	userAgent       string
+	limit           int
+	freeOnly        bool
+	paid            bool
+	location        *time.Location
+	logger          *slog.Logger
+	hooks           *Hooks
+	probeMedia      bool
+	fullText        *fullText
+}
+
+// Option is a per-call setting of GetFeedWithOptions and GetSeriesWithOptions.
+type Option struct {
+	// site is non-empty if only some sites support the option.
+	site  SiteOption
+	apply func(o *callOptions)
+}
+
+// WithIfNoneMatch sends If-None-Match. ErrNotModified is returned if the feed is not modified.
+func WithIfNoneMatch(etag string) Option {
+	return Option{apply: func(o *callOptions) { o.ifNoneMatch = etag }}
+}
+
+// WithIfModifiedSince sends If-Modified-Since. ErrNotModified is returned if the feed is not modified.
+func WithIfModifiedSince(lastModified string) Option {
+	return Option{apply: func(o *callOptions) { o.ifModifiedSince = lastModified }}
+}
+
+// WithClient uses c to fetch instead of the Client set by SetClient or DefaultClient.
+func WithClient(c *Client) Option {
+	return Option{apply: func(o *callOptions) { o.client = c }}
+}
+
+// WithUserAgent overrides User-Agent of the client.
+func WithUserAgent(userAgent string) Option {
+	return Option{apply: func(o *callOptions) { o.userAgent = userAgent }}
+}
+
+// WithLimit keeps at most n latest episodes. zero means unlimited.
+func WithLimit(n int) Option {
+	return Option{apply: func(o *callOptions) { o.limit = n }}
+}
+
+// WithFreeOnly keeps only episodes free to read. (site option)
+func WithFreeOnly() Option {
+	return Option{site: SiteOptionFreeOnly, apply: func(o *callOptions) { o.freeOnly = true }}
+}
+
+// WithPaid also keeps paid episodes of the sites which output only free ones
+// by default. WithFreeOnly takes precedence. (site option)
+func WithPaid() Option {
+	return Option{site: SiteOptionPaid, apply: func(o *callOptions) { o.paid = true }}
+}
+
+// WithLocation parses dates published without the timezone in loc
+// instead of Asia/Tokyo. (site option)
+func WithLocation(loc *time.Location) Option {
+	return Option{site: SiteOptionLocation, apply: func(o *callOptions) { o.location = loc }}
+}
+
+type callOptionsType string
+
+const callOptionsKey = callOptionsType("callOptions")
+
+// withOptions validates opts for loader and returns ctx carrying them.
+func withOptions(ctx context.Context, loader Loader, opts []Option) (context.Context, error) {
+	supported := siteOptions(loader)
+
+	o := &callOptions{}
+	for _, opt := range opts {
+		if opt.site != "" && !slices.Contains(supported, opt.site) {
+			return nil, &UnsupportedOptionError{Site: loader.Name(), Option: opt.site}
+		}
+		opt.apply(o)
+	}
+
+	ctx = SetIfNoneMatch(ctx, o.ifNoneMatch)
+	ctx = SetIfModifiedSince(ctx, o.ifModifiedSince)
+	if o.client != nil {
+		ctx = SetClient(ctx, o.client)
+	}
+	if o.userAgent != "" {
+		c := *getClient(ctx)
+		c.UserAgent = o.userAgent
+		ctx = SetClient(ctx, &c)
+	}
+	ctx = SetLogger(ctx, o.logger)
+	ctx = SetHooks(ctx, o.hooks)
+
+	return context.WithValue(ctx, callOptionsKey, o), nil
+}
+
+func getCallOptions(ctx context.Context) *callOptions {
+	if o, ok := ctx.Value(callOptionsKey).(*callOptions); ok {
+		return o
+	}
+	return &callOptions{}
+}
+
+// paidExcluded reports whether the sites supporting SiteOptionPaid skip paid episodes.
+func paidExcluded(ctx context.Context) bool {
+	o := getCallOptions(ctx)
+	return o.freeOnly || !o.paid
+}
+
+// siteLocation returns the timezone of dates published without it.
+func siteLocation(ctx context.Context) (*time.Location, error) {
+	if loc := getCallOptions(ctx).location; loc != nil {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		return nil, fmt.Errorf("failure to load Asia/Tokyo timezone: %w", err)
+	}
+	return loc, nil
+}
+
+// limitEpisodes keeps at most n latest episodes in their order. undated ones are the oldest.
+func limitEpisodes(series *Series, n int) {
+	if n <= 0 || len(series.Episodes) <= n {
+		return
+	}
+
+	latest := func(ep *Episode) time.Time {
+		if ep.Updated.After(ep.Created) {
+			return ep.Updated
+		}
+		return ep.Created
+	}
+
+	sorted := slices.Clone(series.Episodes)
+	slices.SortStableFunc(sorted, func(x, y *Episode) int {
+		return latest(y).Compare(latest(x))
+	})
+	kept := make(map[*Episode]bool, n)
+	for _, ep := range sorted[:n] {
+		kept[ep] = true
+	}
+
+	series.Episodes = slices.DeleteFunc(series.Episodes, func(ep *Episode) bool {
+		return !kept[ep]
+	})
+}
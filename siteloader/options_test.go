@@ -0,0 +1,108 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionsUnsupported(t *testing.T) {
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", "https://example.com/", func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		return &Series{Key: "test"}, HttpMetadata{}, nil
+	}, SiteOptionLocation)))
+
+	_, _, err := r.GetSeriesWithOptions(context.Background(), "https://example.com/1", WithLocation(time.UTC), WithLimit(1))
+	assert.Nil(t, err)
+
+	_, _, _, err = r.GetFeedWithOptions(context.Background(), "https://example.com/1", WithFreeOnly())
+	var unsupported *UnsupportedOptionError
+	assert.True(t, errors.As(err, &unsupported))
+	assert.Equal(t, "test", unsupported.Site)
+	assert.Equal(t, SiteOptionFreeOnly, unsupported.Option)
+	assert.Equal(t, "test does not support option free-only", err.Error())
+	assert.Equal(t, "unsupported_option", ErrorClass(err))
+
+	assert.Equal(t, []SiteInfo{{Name: "test", Enabled: true, Options: []SiteOption{SiteOptionLocation}}}, r.Sites())
+}
+
+func TestOptionsFuz(t *testing.T) {
+	testsv := newFuzTestServer(t)
+	defer testsv.Close()
+
+	client := WithClient(newRewriteClient(t, testsv))
+
+	series, _, err := GetSeriesWithOptions(context.Background(), "https://comic-fuz.com/manga/1234", client, WithFreeOnly())
+	assert.Nil(t, err)
+	assert.Equal(t, "fuz_manga1234_freeOnly", series.Key)
+	assert.Equal(t, 2, len(series.Episodes))
+
+	series, _, err = GetSeriesWithOptions(context.Background(), "https://comic-fuz.com/manga/1234", client, WithLocation(time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(series.Episodes))
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), series.Episodes[0].Updated)
+
+	series, metadata, err := GetSeriesWithOptions(context.Background(), "https://comic-fuz.com/manga/1234", client, WithLimit(2))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(series.Episodes))
+	assert.Equal(t, "https://comic-fuz.com/manga/viewer/222", series.Episodes[0].Link)
+	assert.Equal(t, "https://comic-fuz.com/manga/viewer/333", series.Episodes[1].Link)
+
+	_, _, err = GetSeriesWithOptions(context.Background(), "https://comic-fuz.com/manga/1234", client, WithLimit(2), WithIfNoneMatch(metadata.ETag))
+	assert.True(t, errors.Is(err, ErrNotModified))
+
+	_, _, err = GetSeriesWithOptions(context.Background(), "https://ncode.syosetu.com/n0000a/", client, WithFreeOnly())
+	var unsupported *UnsupportedOptionError
+	assert.True(t, errors.As(err, &unsupported))
+}
+
+func TestOptionsUserAgent(t *testing.T) {
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("User-Agent"))
+	}))
+	defer testsv.Close()
+
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", testsv.URL, func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		doc, _, err := fetchDocument(ctx, target)
+		if err != nil {
+			return nil, HttpMetadata{}, err
+		}
+		return &Series{Key: "test", Title: doc.Text()}, HttpMetadata{}, nil
+	})))
+
+	series, _, err := r.GetSeriesWithOptions(context.Background(), testsv.URL, WithUserAgent("Urawa"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Urawa", series.Title)
+	// DefaultClient is not modified
+	assert.Equal(t, "Saitama", DefaultClient.UserAgent)
+
+	series, _, err = r.GetSeriesWithOptions(context.Background(), testsv.URL, WithClient(&Client{UserAgent: "Omiya"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "Omiya", series.Title)
+}
+
+func TestLimitEpisodes(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC) }
+	series := &Series{Episodes: []*Episode{
+		{ID: "1", Created: day(1)},
+		{ID: "2", Created: day(2), Updated: day(10)},
+		{ID: "3"},
+		{ID: "4", Created: day(4)},
+	}}
+
+	limitEpisodes(series, 2)
+	assert.Equal(t, 2, len(series.Episodes))
+	assert.Equal(t, "2", series.Episodes[0].ID)
+	assert.Equal(t, "4", series.Episodes[1].ID)
+
+	limitEpisodes(series, 0)
+	assert.Equal(t, 2, len(series.Episodes))
+}
@@ -0,0 +1,316 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/feeds"
+)
+
+// Loader loads a series from the pages of a supported site.
+type Loader interface {
+	// Name returns the unique name of the site (e.g. "meteor").
+	Name() string
+	// Match reports whether target is handled by this loader.
+	Match(target *url.URL) bool
+	// Load fetches target and returns its series.
+	Load(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error)
+}
+
+// SiteOptionLoader is a Loader which supports site options.
+type SiteOptionLoader interface {
+	Loader
+	// SiteOptions returns the site options the loader supports.
+	SiteOptions() []SiteOption
+}
+
+// LoaderFunc is the signature of the function which loads a series.
+type LoaderFunc func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error)
+
+type prefixLoader struct {
+	name    string
+	prefix  string
+	load    LoaderFunc
+	options []SiteOption
+}
+
+// NewPrefixLoader returns a Loader which handles URLs starting with prefix.
+// options are the site options load supports.
+func NewPrefixLoader(name, prefix string, load LoaderFunc, options ...SiteOption) Loader {
+	return &prefixLoader{name: name, prefix: prefix, load: load, options: options}
+}
+
+func (l *prefixLoader) SiteOptions() []SiteOption {
+	return l.options
+}
+
+func (l *prefixLoader) Name() string {
+	return l.name
+}
+
+func (l *prefixLoader) Match(target *url.URL) bool {
+	return strings.HasPrefix(target.String(), l.prefix)
+}
+
+func (l *prefixLoader) Load(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+	return l.load(ctx, target)
+}
+
+// siteOptions returns the site options l supports.
+func siteOptions(l Loader) []SiteOption {
+	if sl, ok := l.(SiteOptionLoader); ok {
+		return sl.SiteOptions()
+	}
+	return nil
+}
+
+// SiteInfo describes a loader registered in a Registry.
+type SiteInfo struct {
+	Name    string
+	Enabled bool
+	// Options are the site options supported.
+	Options []SiteOption
+}
+
+type registryEntry struct {
+	loader  Loader
+	enabled bool
+}
+
+// Registry holds loaders. The first enabled loader matching the target is used.
+type Registry struct {
+	mu      sync.RWMutex
+	entries []*registryEntry
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds l to the registry as enabled.
+func (r *Registry) Register(l Loader) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.entries {
+		if e.loader.Name() == l.Name() {
+			return fmt.Errorf("site %q already registered", l.Name())
+		}
+	}
+	r.entries = append(r.entries, &registryEntry{loader: l, enabled: true})
+	return nil
+}
+
+// Replace replaces the loader named as l with l, keeping whether it is enabled.
+// l is added as enabled if no loader has the name.
+func (r *Registry) Replace(l Loader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.entries {
+		if e.loader.Name() == l.Name() {
+			e.loader = l
+			return
+		}
+	}
+	r.entries = append(r.entries, &registryEntry{loader: l, enabled: true})
+}
+
+// Enable enables the loader named name.
+func (r *Registry) Enable(name string) error {
+	return r.setEnabled(name, true)
+}
+
+// Disable disables the loader named name.
+func (r *Registry) Disable(name string) error {
+	return r.setEnabled(name, false)
+}
+
+func (r *Registry) setEnabled(name string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.entries {
+		if e.loader.Name() == name {
+			e.enabled = enabled
+			return nil
+		}
+	}
+	return fmt.Errorf("site %q not registered", name)
+}
+
+// Sites returns registered sites in order of registration.
+func (r *Registry) Sites() []SiteInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sites := make([]SiteInfo, 0, len(r.entries))
+	for _, e := range r.entries {
+		sites = append(sites, SiteInfo{Name: e.loader.Name(), Enabled: e.enabled, Options: siteOptions(e.loader)})
+	}
+	return sites
+}
+
+// Lookup returns the first enabled loader which handles target.
+func (r *Registry) Lookup(target *url.URL) (Loader, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.entries {
+		if e.enabled && e.loader.Match(target) {
+			return e.loader, true
+		}
+	}
+	return nil, false
+}
+
+// GetSeries loads the series of target with the matching loader.
+func (r *Registry) GetSeries(ctx context.Context, target string) (*Series, HttpMetadata, error) {
+	return r.GetSeriesWithOptions(ctx, target)
+}
+
+// GetSeriesWithOptions loads the series of target with the matching loader and opts.
+// UnsupportedOptionError is returned if the loader does not support a site option in opts.
+func (r *Registry) GetSeriesWithOptions(ctx context.Context, target string, opts ...Option) (*Series, HttpMetadata, error) {
+	uri, err := url.Parse(target)
+	if err != nil {
+		return nil, HttpMetadata{}, &UnsupportedSiteError{URL: target, Err: err}
+	}
+
+	loader, ok := r.Lookup(uri)
+	if !ok {
+		return nil, HttpMetadata{}, &UnsupportedSiteError{URL: target}
+	}
+
+	if len(opts) > 0 {
+		ctx, err = withOptions(ctx, loader, opts)
+		if err != nil {
+			return nil, HttpMetadata{}, err
+		}
+	}
+
+	logger := getLogger(ctx).With("site", loader.Name(), "target", target)
+	ctx = SetLogger(ctx, logger)
+	ctx = withSite(ctx, loader.Name())
+
+	started := time.Now()
+	series, metadata, err := load(ctx, loader, uri)
+	duration := time.Since(started)
+
+	event := LoadEvent{Site: loader.Name(), Target: target, Duration: duration}
+	switch {
+	case err == nil:
+		logger.Debug("loaded", "episodes", len(series.Episodes), "duration", duration)
+		event.Items = len(series.Episodes)
+	case errors.Is(err, ErrNotModified):
+		logger.Debug("not modified", "duration", duration)
+		event.NotModified = true
+	default:
+		logger.Debug("load failed", "error", err, "error_class", ErrorClass(err), "duration", duration)
+		event.Err = err
+	}
+	getHooks(ctx).load(event)
+
+	return series, metadata, err
+}
+
+// load loads the series with loader and applies the settings in ctx.
+func load(ctx context.Context, loader Loader, uri *url.URL) (*Series, HttpMetadata, error) {
+	series, metadata, err := loader.Load(ctx, uri)
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	if store := getStateStore(ctx); store != nil {
+		store.apply(series)
+	}
+	if archive := getArchive(ctx); archive != nil {
+		archive.merge(series)
+	}
+	limitEpisodes(series, getCallOptions(ctx).limit)
+
+	// many upstreams give no validators. compute them from the content.
+	etag, updated, err := contentValidators(series)
+	if err != nil {
+		return nil, metadata, err
+	}
+	fillValidators(&metadata, etag, updated)
+
+	if err := checkNotModified(ctx, metadata); err != nil {
+		return nil, metadata, err
+	}
+
+	// after checkNotModified not to probe nor fetch bodies for 304.
+	if getProbeMedia(ctx) {
+		probeThumbnails(ctx, series)
+	}
+	if ft := getFullText(ctx); ft != nil {
+		if bl, ok := loader.(BodyLoader); ok && !ft.loadBodies(ctx, bl, series) {
+			// the validators cover only the episodes. without them, clients get
+			// the rest of bodies on the next request instead of 304.
+			metadata.ETag = ""
+			metadata.LastModified = ""
+		}
+	}
+
+	return series, metadata, nil
+}
+
+// GetFeed generates the feed of target with the matching loader.
+// returns file name(without extension), feed, metadata and error.
+func (r *Registry) GetFeed(ctx context.Context, target string) (string, *feeds.Feed, HttpMetadata, error) {
+	return r.GetFeedWithOptions(ctx, target)
+}
+
+// GetFeedWithOptions generates the feed of target with the matching loader and opts.
+// See GetSeriesWithOptions.
+func (r *Registry) GetFeedWithOptions(ctx context.Context, target string, opts ...Option) (string, *feeds.Feed, HttpMetadata, error) {
+	series, metadata, err := r.GetSeriesWithOptions(ctx, target, opts...)
+	if err != nil {
+		return "", nil, metadata, err
+	}
+
+	return series.Key, seriesFeed(ctx, series), metadata, nil
+}
+
+// seriesFeed renders the series with the IDs set by SetLegacyIDs.
+func seriesFeed(ctx context.Context, series *Series) *feeds.Feed {
+	if getLegacyIDs(ctx) {
+		return series.LegacyFeed()
+	}
+	return series.Feed()
+}
+
+// DefaultRegistry is the registry used by GetFeed. Built-in sites are registered.
+var DefaultRegistry = NewRegistry()
+
+// Register adds l to DefaultRegistry.
+func Register(l Loader) error {
+	return DefaultRegistry.Register(l)
+}
+
+func init() {
+	builtins := []Loader{
+		NewPrefixLoader("meteor", "https://kirapo.jp/", meteorFeed),
+		NewPrefixLoader("valkyrie", "https://www.comic-valkyrie.com/", valkyrieFeed),
+		NewBodyLoader("narou", "https://ncode.syosetu.com/", narouFeed, narouBody, SiteOptionLocation),
+		NewBodyLoader("kakuyomu", "https://kakuyomu.jp/works/", kakuyomuFeed, kakuyomuBody),
+		NewPrefixLoader("fuz", "https://comic-fuz.com/manga/", fuzFeed, SiteOptionFreeOnly, SiteOptionLocation),
+		NewPrefixLoader("comicwalker", "https://comic-walker.com/detail/", comicwalkerFeed),
+		NewPrefixLoader("ganganonline", "https://www.ganganonline.com/title/", ganganonlineFeed),
+		NewPrefixLoader("takecomi", "https://takecomic.jp/series/", takecomiFeed, SiteOptionFreeOnly, SiteOptionPaid),
+		NewPrefixLoader("alphapolis", "https://www.alphapolis.co.jp/manga/official/", alphapolisMOFeed, SiteOptionFreeOnly, SiteOptionPaid, SiteOptionLocation),
+	}
+
+	for _, l := range builtins {
+		if err := Register(l); err != nil {
+			panic(err)
+		}
+	}
+}
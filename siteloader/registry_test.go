@@ -0,0 +1,79 @@
+package siteloader
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	called := ""
+	newLoader := func(name, prefix string) Loader {
+		return NewPrefixLoader(name, prefix, func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+			called = name
+			return &Series{Site: name, Key: name, Title: target.String()}, HttpMetadata{}, nil
+		})
+	}
+
+	assert.Nil(t, r.Register(newLoader("first", "https://example.com/first/")))
+	assert.Nil(t, r.Register(newLoader("any", "https://example.com/")))
+	assert.Error(t, r.Register(newLoader("any", "https://example.net/")))
+
+	fname, feed, _, err := r.GetFeed(context.Background(), "https://example.com/first/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "first", fname)
+	assert.Equal(t, "first", called)
+	assert.Equal(t, "https://example.com/first/1", feed.Title)
+
+	assert.Nil(t, r.Disable("first"))
+	fname, _, _, err = r.GetFeed(context.Background(), "https://example.com/first/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "any", fname)
+
+	assert.Equal(t, []SiteInfo{{Name: "first", Enabled: false}, {Name: "any", Enabled: true}}, r.Sites())
+
+	assert.Nil(t, r.Enable("first"))
+	fname, _, _, err = r.GetFeed(context.Background(), "https://example.com/first/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "first", fname)
+
+	assert.Error(t, r.Disable("unknown"))
+
+	_, feed, _, err = r.GetFeed(context.Background(), "https://example.net/")
+	assert.Nil(t, feed)
+	assert.Error(t, err)
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	testcases := []struct {
+		target string
+		name   string
+	}{
+		{target: "https://kirapo.jp/meteor/titles/test", name: "meteor"},
+		{target: "https://www.comic-valkyrie.com/test/", name: "valkyrie"},
+		{target: "https://ncode.syosetu.com/n0000aa/", name: "narou"},
+		{target: "https://kakuyomu.jp/works/0000", name: "kakuyomu"},
+		{target: "https://comic-fuz.com/manga/0000", name: "fuz"},
+		{target: "https://comic-walker.com/detail/KC_0000", name: "comicwalker"},
+		{target: "https://www.ganganonline.com/title/0000", name: "ganganonline"},
+		{target: "https://takecomic.jp/series/0000", name: "takecomi"},
+		{target: "https://www.alphapolis.co.jp/manga/official/0000", name: "alphapolis"},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			uri, _ := url.Parse(tt.target)
+			loader, ok := DefaultRegistry.Lookup(uri)
+			assert.True(t, ok)
+			assert.Equal(t, tt.name, loader.Name())
+		})
+	}
+
+	uri, _ := url.Parse("https://www.example.com/")
+	_, ok := DefaultRegistry.Lookup(uri)
+	assert.False(t, ok)
+}
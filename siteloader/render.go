@@ -0,0 +1,218 @@
+package siteloader
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/feeds"
+)
+
+// Format is an output format of feeds.
+type Format string
+
+const (
+	FormatAtom Format = "atom"
+	FormatRSS  Format = "rss"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat returns the format named name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case FormatAtom, FormatRSS, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %q", name)
+	}
+}
+
+// Extension returns the file extension of the format. (e.g. ".atom")
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatRSS:
+		return "application/rss+xml"
+	case FormatJSON:
+		return "application/feed+json"
+	default:
+		return "application/atom+xml"
+	}
+}
+
+// formatMediaTypes are the media types of the formats in order of preference.
+var formatMediaTypes = []struct {
+	format    Format
+	mediaType string
+}{
+	{FormatAtom, "application/atom+xml"},
+	{FormatRSS, "application/rss+xml"},
+	{FormatJSON, "application/feed+json"},
+	{FormatJSON, "application/json"},
+}
+
+// NegotiateFormat returns the format preferred by the Accept header.
+// Atom is returned if accept is empty or no format is acceptable.
+func NegotiateFormat(accept string) Format {
+	best, bestQuality := FormatAtom, 0.0
+	for _, m := range formatMediaTypes {
+		if q := acceptQuality(accept, m.mediaType); q > bestQuality {
+			best, bestQuality = m.format, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality of mediaType in the Accept header,
+// given by the most specific media range matching it.
+func acceptQuality(accept, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := 0.0, -1
+	for _, mediaRange := range strings.Split(accept, ",") {
+		rangeType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		var s int
+		switch rangeType {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		quality, specificity = q, s
+	}
+	return quality
+}
+
+// Render renders the series in the format. legacy renders the IDs of former
+// versions (see Series.LegacyFeed) except for JSON Feed.
+func Render(series *Series, f Format, legacy bool) (string, error) {
+	feed := series.Feed()
+	if legacy {
+		feed = series.LegacyFeed()
+	}
+
+	switch f {
+	case FormatAtom:
+		return ToAtom(feed)
+	case FormatRSS:
+		return ToRSS(feed)
+	case FormatJSON:
+		return ToJSONFeed(series)
+	default:
+		return "", fmt.Errorf("unknown format %q", f)
+	}
+}
+
+// ToAtom renders feed as Atom. Unlike feeds.Feed.ToAtom, the ID of the feed is
+// feed.Id instead of the link if it is set, and the image is rendered as the logo.
+func ToAtom(feed *feeds.Feed) (string, error) {
+	atom := (&feeds.Atom{Feed: feed}).AtomFeed()
+	if feed.Id != "" {
+		atom.Id = feed.Id
+	}
+	if feed.Image != nil {
+		atom.Logo = feed.Image.Url
+	}
+	return feeds.ToXML(atom)
+}
+
+// mediaNamespace is the namespace of Media RSS.
+const mediaNamespace = "http://search.yahoo.com/mrss/"
+
+type rssXML struct {
+	XMLName          xml.Name `xml:"rss"`
+	Version          string   `xml:"version,attr"`
+	ContentNamespace string   `xml:"xmlns:content,attr"`
+	MediaNamespace   string   `xml:"xmlns:media,attr"`
+	Channel          *rssChannel
+}
+
+func (r *rssXML) FeedXml() interface{} {
+	return r
+}
+
+type rssChannel struct {
+	*feeds.RssFeed
+	Items []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	*feeds.RssItem
+	Thumbnail *mediaThumbnail
+}
+
+type mediaThumbnail struct {
+	XMLName xml.Name `xml:"media:thumbnail"`
+	URL     string   `xml:"url,attr"`
+}
+
+// ToRSS renders feed as RSS 2.0. Unlike feeds.Feed.ToRss, enclosures without
+// the length or the type are kept as RSS requires both, and image enclosures
+// are also rendered as media:thumbnail of Media RSS.
+func ToRSS(feed *feeds.Feed) (string, error) {
+	rss := (&feeds.Rss{Feed: feed}).RssFeed()
+
+	// managingEditor must be an email address.
+	if feed.Author == nil || feed.Author.Email == "" {
+		rss.ManagingEditor = ""
+	}
+
+	channel := &rssChannel{RssFeed: rss}
+	for i, item := range feed.Items {
+		rendered := &rssItem{RssItem: rss.Items[i]}
+		channel.Items = append(channel.Items, rendered)
+
+		if item.Enclosure == nil || item.Enclosure.Url == "" {
+			continue
+		}
+		enclosure := &feeds.RssEnclosure{
+			Url:    item.Enclosure.Url,
+			Type:   item.Enclosure.Type,
+			Length: item.Enclosure.Length,
+		}
+		if enclosure.Type == "" {
+			enclosure.Type = "application/octet-stream"
+		}
+		if enclosure.Length == "" {
+			// unknown
+			enclosure.Length = "0"
+		}
+		rendered.Enclosure = enclosure
+
+		if item.Enclosure.Type == "" || strings.HasPrefix(item.Enclosure.Type, "image/") {
+			rendered.Thumbnail = &mediaThumbnail{URL: item.Enclosure.Url}
+		}
+	}
+	rss.Items = nil
+
+	return feeds.ToXML(&rssXML{
+		Version:          "2.0",
+		ContentNamespace: "http://purl.org/rss/1.0/modules/content/",
+		MediaNamespace:   mediaNamespace,
+		Channel:          channel,
+	})
+}
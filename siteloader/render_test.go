@@ -0,0 +1,170 @@
+package siteloader
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToRSS(t *testing.T) {
+	series := &Series{
+		Key:         "test_1",
+		Title:       "テストタイトル",
+		Link:        "https://example.com/1",
+		Description: "テストストーリー",
+		Author:      "テスト著者",
+		Thumbnail:   "https://example.com/1/cover.png",
+		Episodes: []*Episode{
+			{
+				ID:        "ep1",
+				Title:     "サブタイトル1",
+				Link:      "https://example.com/1/1",
+				Thumbnail: "https://example.com/1/1.jpg?w=100",
+				Created:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Updated:   time.Date(2024, 1, 3, 3, 4, 5, 0, time.UTC),
+			},
+			{
+				ID:        "ep2",
+				Title:     "サブタイトル2",
+				Link:      "https://example.com/1/2",
+				Thumbnail: "https://example.com/1/2",
+				Updated:   time.Date(2024, 1, 4, 3, 4, 5, 0, time.UTC),
+			},
+			{
+				ID:    "ep3",
+				Title: "サブタイトル3",
+				Link:  "https://example.com/1/3",
+			},
+		},
+	}
+
+	rendered, err := Render(series, FormatRSS, false)
+	assert.Nil(t, err)
+	assert.Contains(t, rendered, `xmlns:media="http://search.yahoo.com/mrss/"`)
+
+	var rss struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title          string `xml:"title"`
+			Link           string `xml:"link"`
+			ManagingEditor string `xml:"managingEditor"`
+			Image          struct {
+				URL string `xml:"url"`
+			} `xml:"image"`
+			Items []struct {
+				Guid struct {
+					ID          string `xml:",chardata"`
+					IsPermaLink string `xml:"isPermaLink,attr"`
+				} `xml:"guid"`
+				PubDate   string `xml:"pubDate"`
+				Enclosure *struct {
+					URL    string `xml:"url,attr"`
+					Type   string `xml:"type,attr"`
+					Length string `xml:"length,attr"`
+				} `xml:"enclosure"`
+				Thumbnail *struct {
+					URL string `xml:"url,attr"`
+				} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	assert.Nil(t, xml.Unmarshal([]byte(rendered), &rss))
+
+	assert.Equal(t, "2.0", rss.Version)
+	assert.Equal(t, "テストタイトル", rss.Channel.Title)
+	assert.Equal(t, "https://example.com/1", rss.Channel.Link)
+	assert.Equal(t, "", rss.Channel.ManagingEditor)
+	assert.Equal(t, "https://example.com/1/cover.png", rss.Channel.Image.URL)
+	assert.Equal(t, 3, len(rss.Channel.Items))
+
+	first := rss.Channel.Items[0]
+	assert.Equal(t, "tag:3pf.jp,2024:comic2atom/test_1/ep1", first.Guid.ID)
+	assert.Equal(t, "false", first.Guid.IsPermaLink)
+	assert.Equal(t, "Tue, 02 Jan 2024 03:04:05 +0000", first.PubDate)
+	assert.Equal(t, "https://example.com/1/1.jpg?w=100", first.Enclosure.URL)
+	assert.Equal(t, "image/jpeg", first.Enclosure.Type)
+	assert.Equal(t, "0", first.Enclosure.Length)
+	assert.Equal(t, "https://example.com/1/1.jpg?w=100", first.Thumbnail.URL)
+
+	second := rss.Channel.Items[1]
+	assert.Equal(t, "Thu, 04 Jan 2024 03:04:05 +0000", second.PubDate)
+	assert.Equal(t, "application/octet-stream", second.Enclosure.Type)
+	assert.Equal(t, "https://example.com/1/2", second.Thumbnail.URL)
+
+	third := rss.Channel.Items[2]
+	assert.Equal(t, "", third.PubDate)
+	assert.Nil(t, third.Enclosure)
+	assert.Nil(t, third.Thumbnail)
+}
+
+func TestToAtomMedia(t *testing.T) {
+	series := &Series{
+		Key:       "test_1",
+		Title:     "テストタイトル",
+		Link:      "https://example.com/1",
+		Thumbnail: "https://example.com/1/cover.png",
+		Episodes: []*Episode{
+			{ID: "ep1", Link: "https://example.com/1/1", Thumbnail: "https://example.com/1/1.webp", ThumbnailLength: 1234},
+		},
+	}
+
+	rendered, err := Render(series, FormatAtom, false)
+	assert.Nil(t, err)
+
+	var atom struct {
+		Logo    string `xml:"logo"`
+		Entries []struct {
+			Links []struct {
+				Href   string `xml:"href,attr"`
+				Rel    string `xml:"rel,attr"`
+				Type   string `xml:"type,attr"`
+				Length string `xml:"length,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+	assert.Nil(t, xml.Unmarshal([]byte(rendered), &atom))
+	assert.Equal(t, "https://example.com/1/cover.png", atom.Logo)
+	assert.Equal(t, 2, len(atom.Entries[0].Links))
+	enclosure := atom.Entries[0].Links[1]
+	assert.Equal(t, "enclosure", enclosure.Rel)
+	assert.Equal(t, "https://example.com/1/1.webp", enclosure.Href)
+	assert.Equal(t, "image/webp", enclosure.Type)
+	assert.Equal(t, "1234", enclosure.Length)
+}
+
+func TestFormat(t *testing.T) {
+	f, err := ParseFormat("rss")
+	assert.Nil(t, err)
+	assert.Equal(t, FormatRSS, f)
+	assert.Equal(t, ".rss", f.Extension())
+	assert.Equal(t, "application/rss+xml", f.ContentType())
+
+	f, err = ParseFormat("atom")
+	assert.Nil(t, err)
+	assert.Equal(t, ".atom", f.Extension())
+	assert.Equal(t, "application/atom+xml", f.ContentType())
+
+	_, err = ParseFormat("rdf")
+	assert.Error(t, err)
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	for accept, expected := range map[string]Format{
+		"":                      FormatAtom,
+		"*/*":                   FormatAtom,
+		"text/html":             FormatAtom,
+		"application/rss+xml":   FormatRSS,
+		"application/feed+json": FormatJSON,
+		"application/json":      FormatJSON,
+		"application/rss+xml, application/atom+xml":                      FormatAtom,
+		"application/rss+xml, application/atom+xml;q=0.9, */*;q=0.1":     FormatRSS,
+		"application/json;q=0.5, application/*;q=0.1":                    FormatJSON,
+		"application/*;q=0.8, application/atom+xml;q=0.2":                FormatRSS,
+		"text/html, application/xhtml+xml, */*;q=0.8":                    FormatAtom,
+		"application/atom+xml;q=0, application/rss+xml;q=0.5, invalid;;": FormatRSS,
+	} {
+		assert.Equal(t, expected, NegotiateFormat(accept), accept)
+	}
+}
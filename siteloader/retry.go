@@ -0,0 +1,151 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures retries of failed requests.
+// Connection errors and 429/500/502/503/504 responses are retried.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt. zero disables retries.
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, doubled on each retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay. Retry-After longer than this is still honored.
+	MaxDelay time.Duration
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the jittered delay before the n-th retry(0-origin).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < n && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// randomize between half and full delay.
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// parseRetryAfter parses Retry-After header as delay-seconds or HTTP-date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if at.Before(now) {
+		return 0, true
+	}
+	return at.Sub(now), true
+}
+
+// retryDelay returns the delay before the n-th retry and whether to retry.
+func (p RetryPolicy) retryDelay(n int, res *http.Response, err error) (time.Duration, bool) {
+	if n >= p.MaxRetries {
+		return 0, false
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+		return p.backoff(n), true
+	}
+
+	if !isRetryableStatus(res.StatusCode) {
+		return 0, false
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+		if delay, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+			return delay, true
+		}
+	}
+
+	return p.backoff(n), true
+}
+
+// sleepContext waits delay unless ctx is done. returns false if the wait
+// would exceed the deadline of ctx or ctx is done.
+func sleepContext(ctx context.Context, delay time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// doWithRetry sends req with doer and retries following p.
+func (p RetryPolicy) doWithRetry(doer Doer, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for n := 0; ; n++ {
+		res, err := doer.Do(req)
+
+		// cannot resend the consumed body.
+		if req.Body != nil && req.GetBody == nil {
+			return res, err
+		}
+
+		delay, retry := p.retryDelay(n, res, err)
+		if !retry || !sleepContext(ctx, delay) {
+			return res, err
+		}
+
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
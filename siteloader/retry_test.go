@@ -0,0 +1,172 @@
+package siteloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{name: "empty", value: "", ok: false},
+		{name: "seconds", value: "120", want: 120 * time.Second, ok: true},
+		{name: "negative", value: "-1", ok: false},
+		{name: "date", value: "Wed, 21 Oct 2015 07:28:30 GMT", want: 30 * time.Second, ok: true},
+		{name: "past date", value: "Wed, 21 Oct 2015 07:27:00 GMT", want: 0, ok: true},
+		{name: "invalid", value: "saitama", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for n, want := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		got := p.backoff(n)
+		assert.True(t, got >= want/2 && got <= want, "backoff(%d) = %v, want %v/2..%v", n, got, want, want)
+	}
+
+	assert.Equal(t, time.Duration(0), RetryPolicy{}.backoff(3))
+}
+
+func TestClientRetry(t *testing.T) {
+	count := 0
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		switch count {
+		case 1:
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Close()
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 3:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			fmt.Fprint(w, "example")
+		}
+	}))
+	defer testsv.Close()
+
+	testUrl, _ := url.Parse(testsv.URL)
+
+	ctx := SetClient(context.Background(), &Client{
+		Retry: RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond},
+	})
+	doc, _, err := fetchDocument(ctx, testUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, "example", doc.Text())
+	assert.Equal(t, 4, count)
+}
+
+func TestClientRetryExhausted(t *testing.T) {
+	count := 0
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testsv.Close()
+
+	c := &Client{Retry: RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}}
+	req, _ := http.NewRequest(http.MethodGet, testsv.URL, nil)
+	res, err := c.Do(req)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, 3, count)
+}
+
+func TestClientRetryNotRetryable(t *testing.T) {
+	count := 0
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer testsv.Close()
+
+	c := &Client{Retry: RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}}
+	req, _ := http.NewRequest(http.MethodGet, testsv.URL, nil)
+	res, err := c.Do(req)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, 1, count)
+}
+
+func TestClientRetryPostBody(t *testing.T) {
+	count := 0
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "saitama", string(body))
+		if count == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "example")
+	}))
+	defer testsv.Close()
+
+	c := &Client{Retry: RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}}
+	req, _ := http.NewRequest(http.MethodPost, testsv.URL, bytes.NewReader([]byte("saitama")))
+	res, err := c.Do(req)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 2, count)
+}
+
+func TestClientRetryDeadline(t *testing.T) {
+	count := 0
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer testsv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := &Client{Retry: RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testsv.URL, nil)
+
+	started := time.Now()
+	res, err := c.Do(req)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, 1, count)
+	assert.True(t, time.Since(started) < time.Second)
+}
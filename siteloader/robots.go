@@ -0,0 +1,249 @@
+package siteloader
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RobotsDisallowedError is returned when robots.txt disallows the request.
+type RobotsDisallowedError struct {
+	URL string
+}
+
+func (e *RobotsDisallowedError) Error() string {
+	return fmt.Sprintf("%s is disallowed by robots.txt", e.URL)
+}
+
+// maximum size of robots.txt to read (RFC 9309 requires at least 500KiB).
+const robotsMaxSize = 500 * 1024
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// parseRobots parses robots.txt and returns the rules for agent.
+func parseRobots(r io.Reader, agent string) robotsRules {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || (key == "disallow" && value == "") {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	agent = strings.ToLower(agent)
+
+	// merge groups for the agent. groups for "*" are used if none matches.
+	var matched, wildcard robotsRules
+	found := false
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a == "*" {
+				wildcard.rules = append(wildcard.rules, g.rules...)
+				wildcard.crawlDelay = max(wildcard.crawlDelay, g.crawlDelay)
+			} else if agent != "" && strings.Contains(agent, a) {
+				found = true
+				matched.rules = append(matched.rules, g.rules...)
+				matched.crawlDelay = max(matched.crawlDelay, g.crawlDelay)
+			} else {
+				continue
+			}
+			break
+		}
+	}
+
+	if found {
+		return matched
+	}
+	return wildcard
+}
+
+// matchRobotsPattern reports whether path matches pattern with `*` and `$`.
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+
+	for i, part := range parts[1:] {
+		last := i == len(parts)-2
+		if last && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+
+	return !anchored || rest == ""
+}
+
+// allowed reports whether path is allowed. the longest match wins and allow wins a tie.
+func (r robotsRules) allowed(path string) bool {
+	allow := true
+	length := -1
+
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		l := len(rule.pattern)
+		if l > length || (l == length && rule.allow) {
+			allow = rule.allow
+			length = l
+		}
+	}
+
+	return allow
+}
+
+type robotsEntry struct {
+	rules   robotsRules
+	expires time.Time
+}
+
+// RobotsPolicy fetches and caches robots.txt of each host and refuses
+// disallowed requests. Crawl-delay is honored.
+// It is safe for concurrent use and meant to be shared in the process.
+type RobotsPolicy struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*robotsEntry
+	delay *HostLimiter
+}
+
+// NewRobotsPolicy returns a RobotsPolicy which caches robots.txt for ttl.
+func NewRobotsPolicy(ttl time.Duration) *RobotsPolicy {
+	return &RobotsPolicy{
+		ttl:   ttl,
+		cache: make(map[string]*robotsEntry),
+		delay: NewHostLimiter(0, 1),
+	}
+}
+
+func (p *RobotsPolicy) getRules(ctx context.Context, doer Doer, target *url.URL, userAgent string) (robotsRules, error) {
+	origin := target.Scheme + "://" + target.Host
+
+	p.mu.Lock()
+	entry, ok := p.cache[origin]
+	p.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.rules, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return robotsRules{}, fmt.Errorf("cannot generate robots.txt request:%w", err)
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+
+	res, err := doer.Do(req)
+	if err != nil {
+		return robotsRules{}, fmt.Errorf("robots.txt fetch error:%w", err)
+	}
+	defer res.Body.Close()
+
+	var rules robotsRules
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		// product token is the part before "/"
+		agent, _, _ := strings.Cut(userAgent, "/")
+		rules = parseRobots(io.LimitReader(res.Body, robotsMaxSize), agent)
+	case res.StatusCode >= 400 && res.StatusCode < 500:
+		// robots.txt is unavailable. everything is allowed.
+	default:
+		return robotsRules{}, fmt.Errorf("robots.txt unreachable:%d(%s)", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	p.mu.Lock()
+	p.cache[origin] = &robotsEntry{rules: rules, expires: time.Now().Add(p.ttl)}
+	p.mu.Unlock()
+
+	p.delay.SetHostInterval(target.Host, rules.crawlDelay)
+
+	return rules, nil
+}
+
+// check returns RobotsDisallowedError if robots.txt disallows target.
+func (p *RobotsPolicy) check(ctx context.Context, doer Doer, target *url.URL, userAgent string) error {
+	rules, err := p.getRules(ctx, doer, target, userAgent)
+	if err != nil {
+		return err
+	}
+
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+
+	if !rules.allowed(path) {
+		return &RobotsDisallowedError{URL: target.String()}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRobots = `# test
+User-agent: Googlebot
+Disallow: /
+
+User-agent: Saitama
+User-agent: Urawa
+Disallow: /private/
+Allow: /private/open
+Disallow: /*.json$
+Crawl-delay: 0.05
+
+User-agent: *
+Disallow: /
+`
+
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots(strings.NewReader(testRobots), "Saitama")
+	assert.Equal(t, 50*time.Millisecond, rules.crawlDelay)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/", want: true},
+		{path: "/works/1", want: true},
+		{path: "/private/", want: false},
+		{path: "/private/secret", want: false},
+		{path: "/private/open", want: true},
+		{path: "/private/openness", want: true},
+		{path: "/api/data.json", want: false},
+		{path: "/api/data.json?x=1", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, rules.allowed(tt.path))
+		})
+	}
+
+	rules = parseRobots(strings.NewReader(testRobots), "Omiya")
+	assert.False(t, rules.allowed("/works/1"))
+	assert.Equal(t, time.Duration(0), rules.crawlDelay)
+
+	rules = parseRobots(strings.NewReader(""), "Omiya")
+	assert.True(t, rules.allowed("/works/1"))
+}
+
+func TestMatchRobotsPattern(t *testing.T) {
+	assert.True(t, matchRobotsPattern("/fish", "/fish.html"))
+	assert.False(t, matchRobotsPattern("/fish", "/Fish"))
+	assert.True(t, matchRobotsPattern("/fish*", "/fishheads"))
+	assert.True(t, matchRobotsPattern("/*.php", "/folder/filename.php?parameters"))
+	assert.False(t, matchRobotsPattern("/*.php", "/windows.PHP"))
+	assert.True(t, matchRobotsPattern("/*.php$", "/filename.php"))
+	assert.False(t, matchRobotsPattern("/*.php$", "/filename.php?parameters"))
+	assert.True(t, matchRobotsPattern("/fish*.php", "/fishheads/catfish.php?parameters"))
+	assert.True(t, matchRobotsPattern("/fish$", "/fish"))
+	assert.False(t, matchRobotsPattern("/fish$", "/fishes"))
+}
+
+func TestClientRobots(t *testing.T) {
+	robotsCount := 0
+	accessed := 0
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsCount++
+			fmt.Fprint(w, testRobots)
+			return
+		}
+		accessed++
+		fmt.Fprint(w, "example")
+	}))
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), &Client{UserAgent: "Saitama/1.0", Robots: NewRobotsPolicy(time.Hour)})
+
+	allowedUrl, _ := url.Parse(testsv.URL + "/works/1")
+	started := time.Now()
+	for i := 0; i < 2; i++ {
+		doc, _, err := fetchDocument(ctx, allowedUrl)
+		assert.Nil(t, err)
+		assert.Equal(t, "example", doc.Text())
+	}
+	assert.Equal(t, 1, robotsCount)
+	assert.Equal(t, 2, accessed)
+	// Crawl-delay
+	assert.True(t, time.Since(started) >= 50*time.Millisecond)
+
+	disallowedUrl, _ := url.Parse(testsv.URL + "/private/secret")
+	_, _, err := fetchDocument(ctx, disallowedUrl)
+	var robotsErr *RobotsDisallowedError
+	assert.True(t, errors.As(err, &robotsErr))
+	assert.Equal(t, disallowedUrl.String(), robotsErr.URL)
+	assert.Equal(t, 2, accessed)
+}
+
+func TestClientRobotsUnavailable(t *testing.T) {
+	status := http.StatusNotFound
+	testsv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, "example")
+	}))
+	defer testsv.Close()
+
+	testUrl, _ := url.Parse(testsv.URL + "/works/1")
+
+	// missing robots.txt allows everything
+	ctx := SetClient(context.Background(), &Client{Robots: NewRobotsPolicy(time.Hour)})
+	_, _, err := fetchDocument(ctx, testUrl)
+	assert.Nil(t, err)
+
+	// unreachable robots.txt refuses the request
+	status = http.StatusInternalServerError
+	ctx = SetClient(context.Background(), &Client{Robots: NewRobotsPolicy(time.Hour)})
+	_, _, err = fetchDocument(ctx, testUrl)
+	assert.Error(t, err)
+}
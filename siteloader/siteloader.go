@@ -1,7 +1,6 @@
 package siteloader
 
 import (
-	"bytes"
 	"context"
 	"crypto/md5"
 	"encoding/json"
@@ -14,53 +13,32 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gorilla/feeds"
-	"github.com/saintfish/chardet"
-	"golang.org/x/net/html/charset"
 )
 
-func GetFeed(ctx context.Context, target string) (string, *feeds.Feed, HttpMetadata, error) {
-	uri, err := url.Parse(target)
-	if err != nil {
-		return "", nil, HttpMetadata{}, err
-	}
-
-	if strings.HasPrefix(target, "https://kirapo.jp/") {
-		return meteorFeed(ctx, uri)
-	}
-
-	if strings.HasPrefix(target, "https://www.comic-valkyrie.com/") {
-		return valkyrieFeed(ctx, uri)
-	}
-
-	if strings.HasPrefix(target, "https://ncode.syosetu.com/") {
-		return narouFeed(ctx, uri)
-	}
-
-	if strings.HasPrefix(target, "https://kakuyomu.jp/works/") {
-		return kakuyomuFeed(ctx, uri)
-	}
-
-	if strings.HasPrefix(target, "https://comic-fuz.com/manga/") {
-		return fuzFeed(ctx, uri)
-	}
-
-	if strings.HasPrefix(target, "https://comic-walker.com/detail/") {
-		return comicwalkerFeed(ctx, uri)
-	}
+// GetSeries loads the series of target with DefaultRegistry.
+func GetSeries(ctx context.Context, target string) (*Series, HttpMetadata, error) {
+	return DefaultRegistry.GetSeries(ctx, target)
+}
 
-	if strings.HasPrefix(target, "https://www.ganganonline.com/title/") {
-		return ganganonlineFeed(ctx, uri)
-	}
+// GetSeriesWithOptions loads the series of target with DefaultRegistry and opts.
+func GetSeriesWithOptions(ctx context.Context, target string, opts ...Option) (*Series, HttpMetadata, error) {
+	return DefaultRegistry.GetSeriesWithOptions(ctx, target, opts...)
+}
 
-	if strings.HasPrefix(target, "https://takecomic.jp/series/") {
-		return takecomiFeed(ctx, uri)
-	}
+// GetFeed generates the feed of target with DefaultRegistry.
+func GetFeed(ctx context.Context, target string) (string, *feeds.Feed, HttpMetadata, error) {
+	return DefaultRegistry.GetFeed(ctx, target)
+}
 
-	if strings.HasPrefix(target, "https://www.alphapolis.co.jp/manga/official/") {
-		return alphapolisMOFeed(ctx, uri)
-	}
+// GetFeedWithOptions generates the feed of target with DefaultRegistry and opts.
+// returns file name(without extension), feed, metadata and error.
+func GetFeedWithOptions(ctx context.Context, target string, opts ...Option) (string, *feeds.Feed, HttpMetadata, error) {
+	return DefaultRegistry.GetFeedWithOptions(ctx, target, opts...)
+}
 
-	return "", nil, HttpMetadata{}, fmt.Errorf("%s not supported site", target)
+// LoadBodies loads the body of the episodes of series loaded from target with DefaultRegistry.
+func LoadBodies(ctx context.Context, target string, series *Series) error {
+	return DefaultRegistry.LoadBodies(ctx, target, series)
 }
 
 func escapePath(path string) string {
@@ -138,6 +116,12 @@ func SetIfModifiedSince(ctx context.Context, ifModifiedSince string) context.Con
 type HttpMetadata struct {
 	ETag         string
 	LastModified string
+	// ContentType is the Content-Type header of the upstream.
+	ContentType string
+	// Charset is the charset used to decode the document and
+	// CharsetSource tells how it is decided. Empty unless the document is HTML.
+	Charset       string
+	CharsetSource CharsetSource
 }
 
 func getHttpBody(ctx context.Context, target *url.URL) ([]byte, HttpMetadata, error) {
@@ -147,17 +131,11 @@ func getHttpBody(ctx context.Context, target *url.URL) ([]byte, HttpMetadata, er
 	if err != nil {
 		return nil, HttpMetadata{}, fmt.Errorf("cannot generate request:%w", err)
 	}
-	req.Header.Set("User-Agent", "Saitama")
 
 	//set if-none-match and if-modified-since
-	if ifNoneMatch, ok := getIfNoneMatch(ctx); ok {
-		req.Header.Set("If-None-Match", ifNoneMatch)
-	}
-	if ifModifiedSince, ok := getIsModifiedSince(ctx); ok {
-		req.Header.Set("If-Modified-Since", ifModifiedSince)
-	}
+	setConditionalHeaders(ctx, req)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := getClient(ctx).Do(req)
 	if err != nil {
 		return nil, HttpMetadata{}, fmt.Errorf("HTTP/GET error:%w", err)
 	}
@@ -168,6 +146,10 @@ func getHttpBody(ctx context.Context, target *url.URL) ([]byte, HttpMetadata, er
 		return nil, HttpMetadata{}, ErrNotModified
 	}
 
+	if err := checkStatus(res); err != nil {
+		return nil, HttpMetadata{}, err
+	}
+
 	// Read
 	bytesRead, err := io.ReadAll(res.Body)
 	if err != nil {
@@ -177,6 +159,7 @@ func getHttpBody(ctx context.Context, target *url.URL) ([]byte, HttpMetadata, er
 	return bytesRead, HttpMetadata{
 		ETag:         res.Header.Get("ETag"),
 		LastModified: res.Header.Get("Last-Modified"),
+		ContentType:  res.Header.Get("Content-Type"),
 	}, nil
 }
 
@@ -187,19 +170,12 @@ func fetchDocument(ctx context.Context, target *url.URL) (*goquery.Document, Htt
 		return nil, metadata, err
 	}
 
-	// detect charset
-	detector := chardet.NewTextDetector()
-	deetctResult, err := detector.DetectBest(bytesRead)
-	if err != nil {
-		return nil, metadata, fmt.Errorf("charset detect error:%w", err)
-	}
-
 	// convert charset
-	bytesReader := bytes.NewReader(bytesRead)
-	reader, err := charset.NewReaderLabel(deetctResult.Charset, bytesReader)
+	reader, err := newCharsetReader(bytesRead, &metadata)
 	if err != nil {
-		return nil, metadata, fmt.Errorf("charset convert error:%w", err)
+		return nil, metadata, err
 	}
+	getLogger(ctx).Debug("charset", "url", target.String(), "charset", metadata.Charset, "charset_source", metadata.CharsetSource)
 
 	// create document
 	doc, err := goquery.NewDocumentFromReader(reader)
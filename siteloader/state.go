@@ -0,0 +1,93 @@
+package siteloader
+
+import (
+	"context"
+	"time"
+)
+
+type seriesState struct {
+	FirstSeen time.Time `json:"first_seen"`
+	// Entries is first seen time of each episode keyed by its ID.
+	Entries map[string]time.Time `json:"entries"`
+}
+
+// StateStore records when each series and episode is first seen and
+// gives the time to the ones the site publishes no dates for.
+type StateStore struct {
+	jsonFile[*seriesState]
+	now func() time.Time
+}
+
+// OpenStateStore loads the state from path. It is empty if the file does not exist.
+func OpenStateStore(path string) (*StateStore, error) {
+	s := &StateStore{now: time.Now}
+	if err := s.load(path, "state"); err != nil {
+		return nil, err
+	}
+	for key, ss := range s.series {
+		if ss == nil {
+			delete(s.series, key)
+		} else if ss.Entries == nil {
+			ss.Entries = make(map[string]time.Time)
+		}
+	}
+
+	return s, nil
+}
+
+// apply records the series and its episodes, and sets the first seen time to
+// undated ones. The updated time of the series is derived from the episodes.
+// Episodes no longer in the series are forgotten.
+func (s *StateStore) apply(series *Series) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	ss, ok := s.series[series.Key]
+	if !ok {
+		ss = &seriesState{FirstSeen: now, Entries: make(map[string]time.Time)}
+		s.series[series.Key] = ss
+		s.dirty = true
+	}
+
+	entries := make(map[string]time.Time, len(series.Episodes))
+	for _, ep := range series.Episodes {
+		key := episodeKey(ep)
+		seen, ok := ss.Entries[key]
+		if !ok {
+			seen = now
+			s.dirty = true
+		}
+		entries[key] = seen
+
+		if ep.Created.IsZero() && ep.Updated.IsZero() {
+			ep.Created = seen
+		}
+	}
+	if len(entries) != len(ss.Entries) {
+		s.dirty = true
+	}
+	ss.Entries = entries
+
+	if series.Created.IsZero() {
+		series.Created = ss.FirstSeen
+	}
+	if series.Updated.IsZero() {
+		series.Updated = series.latestTime()
+	}
+}
+
+type stateStoreType string
+
+const stateStoreKey = stateStoreType("stateStore")
+
+// SetStateStore sets the StateStore used by GetSeries and GetFeed.
+func SetStateStore(ctx context.Context, store *StateStore) context.Context {
+	return context.WithValue(ctx, stateStoreKey, store)
+}
+
+func getStateStore(ctx context.Context) *StateStore {
+	store, _ := ctx.Value(stateStoreKey).(*StateStore)
+	return store
+}
@@ -0,0 +1,102 @@
+package siteloader
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := OpenStateStore(path)
+	assert.Nil(t, err)
+
+	first := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	second := time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)
+	dated := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	store.now = func() time.Time { return first }
+	series := &Series{
+		Key: "test_1",
+		Episodes: []*Episode{
+			{ID: "ep1"},
+			{ID: "ep2", Updated: dated},
+		},
+	}
+	store.apply(series)
+	assert.Equal(t, first, series.Episodes[0].Created)
+	assert.True(t, series.Episodes[1].Created.IsZero())
+	assert.Equal(t, first, series.Created)
+	assert.Equal(t, first, series.Updated)
+
+	assert.Nil(t, store.Save())
+
+	// reload and see a new episode
+	store, err = OpenStateStore(path)
+	assert.Nil(t, err)
+	store.now = func() time.Time { return second }
+	series = &Series{
+		Key: "test_1",
+		Episodes: []*Episode{
+			{ID: "ep1"},
+			{Link: "https://example.com/1/3"},
+		},
+	}
+	store.apply(series)
+	assert.Equal(t, first, series.Episodes[0].Created)
+	assert.Equal(t, second, series.Episodes[1].Created)
+	assert.Equal(t, first, series.Created)
+	assert.Equal(t, second, series.Updated)
+
+	// ep2 is forgotten
+	assert.Equal(t, 2, len(store.series["test_1"].Entries))
+	assert.Nil(t, store.Save())
+
+	// unchanged state is not written
+	assert.Nil(t, os.Remove(path))
+	store.apply(&Series{
+		Key: "test_1",
+		Episodes: []*Episode{
+			{ID: "ep1"},
+			{Link: "https://example.com/1/3"},
+		},
+	})
+	assert.Nil(t, store.Save())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStateStoreBroken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	assert.Nil(t, os.WriteFile(path, []byte("{"), 0644))
+
+	_, err := OpenStateStore(path)
+	assert.Error(t, err)
+}
+
+func TestRegistryStateStore(t *testing.T) {
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("test", "https://example.com/", func(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+		return &Series{Key: "test_1", Episodes: []*Episode{{ID: "ep1"}}}, HttpMetadata{}, nil
+	})))
+
+	store, err := OpenStateStore(filepath.Join(t.TempDir(), "state.json"))
+	assert.Nil(t, err)
+	ctx := SetStateStore(context.Background(), store)
+
+	series, metadata, err := r.GetSeries(ctx, "https://example.com/1")
+	assert.Nil(t, err)
+	assert.False(t, series.Episodes[0].Created.IsZero())
+	assert.NotEmpty(t, metadata.LastModified)
+
+	// validators are stable for undated episodes
+	_, again, err := r.GetSeries(ctx, "https://example.com/1")
+	assert.Nil(t, err)
+	assert.Equal(t, metadata, again)
+}
@@ -5,19 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
 	"strings"
 	"time"
-
-	"github.com/gorilla/feeds"
 )
 
-func takecomiFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMetadata, error) {
+func takecomiFeed(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
+	freeOnly := paidExcluded(ctx)
+
 	idx := strings.LastIndex(target.Path, "/")
 	idStr := target.Path[idx+1:]
 	if idStr == "" {
-		return "", nil, HttpMetadata{}, errors.New("takecomi:invalid URI")
+		return nil, HttpMetadata{}, &UnsupportedSiteError{URL: target.String(), Err: errors.New("series hash not found")}
 	}
 
 	// try to get total eposodes
@@ -49,30 +48,28 @@ func takecomiFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, Ht
 		} `json:"series"`
 	}
 	seriesSimpleUrl := "https://takecomic.jp/api/episodes?seriesHash=" + idStr
-	seriesSimpleResp, err := http.Get(seriesSimpleUrl)
+	seriesSimpleBody, err := getTakecomiAPI(ctx, seriesSimpleUrl, true)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to fetch(simple) %q :%w", seriesSimpleUrl, err)
+		return nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to fetch(simple) %q :%w", seriesSimpleUrl, err)
 	}
-	defer seriesSimpleResp.Body.Close()
-	err = json.NewDecoder(seriesSimpleResp.Body).Decode(&seriesSimpleData)
+	err = json.Unmarshal(seriesSimpleBody, &seriesSimpleData)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to decode(simple) %q :%w", seriesSimpleUrl, err)
+		return nil, HttpMetadata{}, &LayoutError{Site: "takecomi", Selector: seriesSimpleUrl, Err: err}
 	}
 
 	episodeFrom := max(1, seriesSimpleData.Series.Summary.NumEpisodes-5)
 	description, err := dequoteTalecomiDetails(seriesSimpleData.Series.Summary.Description)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to read description %q :%w", seriesSimpleUrl, err)
+		return nil, HttpMetadata{}, &LayoutError{Site: "takecomi", Selector: "series.summary.description", Err: err}
 	}
 
 	// get eposode details
 	seriesDetailUrl := fmt.Sprintf("https://takecomic.jp/api/episodes?episodeFrom=%d&episodeTo=%d&seriesHash=%s", episodeFrom, seriesSimpleData.Series.Summary.NumEpisodes, idStr)
 
-	seriesResp, err := http.Get(seriesDetailUrl)
+	seriesBody, err := getTakecomiAPI(ctx, seriesDetailUrl, false)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to fetch %q :%w", seriesDetailUrl, err)
+		return nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to fetch %q :%w", seriesDetailUrl, err)
 	}
-	defer seriesResp.Body.Close()
 
 	var seriesDetailData struct {
 		Series struct {
@@ -102,17 +99,16 @@ func takecomiFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, Ht
 		} `json:"series"`
 	}
 
-	err = json.NewDecoder(seriesResp.Body).Decode(&seriesDetailData)
+	err = json.Unmarshal(seriesBody, &seriesDetailData)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to decode %q :%w", seriesDetailUrl, err)
+		return nil, HttpMetadata{}, &LayoutError{Site: "takecomi", Selector: seriesDetailUrl, Err: err}
 	}
 
 	accessUrl := fmt.Sprintf("https://takecomic.jp/api/series/access?episodeFrom=%d&episodeTo=%d&seriesHash=%s", episodeFrom, seriesSimpleData.Series.Summary.NumEpisodes, idStr)
-	accessResp, err := http.Get(accessUrl)
+	accessBody, err := getTakecomiAPI(ctx, accessUrl, false)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to fetch %q :%w", accessUrl, err)
+		return nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to fetch %q :%w", accessUrl, err)
 	}
-	defer accessResp.Body.Close()
 
 	var accessData struct {
 		SeriesAccess struct {
@@ -129,9 +125,9 @@ func takecomiFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, Ht
 		} `json:"seriesAccess"`
 	}
 
-	err = json.NewDecoder(accessResp.Body).Decode(&accessData)
+	err = json.Unmarshal(accessBody, &accessData)
 	if err != nil {
-		return "", nil, HttpMetadata{}, fmt.Errorf("takecomi:failure to decode %q :%w", accessUrl, err)
+		return nil, HttpMetadata{}, &LayoutError{Site: "takecomi", Selector: accessUrl, Err: err}
 	}
 
 	accessMap := make(map[string]bool, len(accessData.SeriesAccess.EpisodeAccesses))
@@ -144,31 +140,70 @@ func takecomiFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, Ht
 		authors = append(authors, fmt.Sprintf("%s(%s)", ac.Name, ac.Role))
 	}
 
-	feed := &feeds.Feed{
+	// free-only feeds, the default, keep their key.
+	paidSuffix := ""
+	if !freeOnly {
+		paidSuffix = "_paid"
+	}
+
+	series := &Series{
+		Site:        "takecomi",
+		Key:         "takecomi_" + escapePath(target.Path) + paidSuffix,
 		Title:       seriesDetailData.Series.Summary.Name,
-		Link:        &feeds.Link{Href: target.String()},
+		Link:        target.String(),
 		Description: description,
-		Author:      &feeds.Author{Name: strings.Join(authors, "/")},
+		Author:      strings.Join(authors, "/"),
+		Completed:   seriesDetailData.Series.Summary.IsCompleted,
 		Created:     time.Time(seriesDetailData.Series.Summary.PublishDate),
 		Updated:     time.Time(seriesDetailData.Series.Summary.UpdatedOn),
 	}
 
 	for _, ep := range seriesDetailData.Series.Episodes {
-		if !accessMap[ep.ID] {
+		// episodes readable without login are free.
+		pricing := PricingPaid
+		if accessMap[ep.ID] {
+			pricing = PricingFree
+		}
+		if freeOnly && pricing != PricingFree {
 			continue
 		}
-		feed.Items = append(feed.Items,
-			&feeds.Item{
-				Title:   ep.Title,
-				Updated: time.Time(ep.DatePublished),
-				Id:      ep.ID,
-				Link: &feeds.Link{
-					Href: "https://takecomic.jp/episodes/" + ep.ID,
-				},
-			})
-	}
-
-	return "takecomi_" + escapePath(target.Path), feed, HttpMetadata{}, nil
+		series.Episodes = append(series.Episodes, &Episode{
+			ID:      ep.ID,
+			Title:   ep.Title,
+			Link:    "https://takecomic.jp/episodes/" + ep.ID,
+			Number:  ep.IndexID,
+			Pricing: pricing,
+			Updated: time.Time(ep.DatePublished),
+		})
+	}
+
+	if len(series.Episodes) == 0 {
+		return nil, HttpMetadata{}, &NoEpisodesError{Site: "takecomi", URL: target.String()}
+	}
+
+	// the feed consists of three responses, so the validators of each one are not usable.
+	var metadata HttpMetadata
+	fillValidators(&metadata, hashETag(series.Key, seriesSimpleBody, seriesBody, accessBody), series.Updated)
+	if err := checkNotModified(ctx, metadata); err != nil {
+		return nil, metadata, fmt.Errorf("takecomi:%w", err)
+	}
+
+	return series, metadata, nil
+}
+
+// getTakecomiAPI gets uri. conditional headers are sent only if conditional is true.
+func getTakecomiAPI(ctx context.Context, uri string, conditional bool) ([]byte, error) {
+	target, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL:%w", err)
+	}
+
+	if !conditional {
+		ctx = withoutConditional(ctx)
+	}
+
+	body, _, err := getHttpBody(ctx, target)
+	return body, err
 }
 
 func dequoteTalecomiDetails(details string) (string, error) {
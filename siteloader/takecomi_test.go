@@ -0,0 +1,184 @@
+package siteloader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTakecomiTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("seriesHash") != "abcdef" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fn := ""
+		switch r.URL.Path {
+		case "/api/episodes":
+			fn = "./testdata/takecomi_test_simple.json"
+			if q.Get("episodeFrom") != "" {
+				if q.Get("episodeFrom") != "1" || q.Get("episodeTo") != "3" {
+					t.Errorf("unexpected range %s-%s", q.Get("episodeFrom"), q.Get("episodeTo"))
+				}
+				fn = "./testdata/takecomi_test_detail.json"
+			}
+		case "/api/series/access":
+			fn = "./testdata/takecomi_test_access.json"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		f, err := os.Open(fn)
+		if err != nil {
+			t.Fatalf("Cannot load test file:%v", err)
+		}
+		defer f.Close()
+		io.Copy(w, f)
+	}))
+}
+
+func TestTakecomi(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+
+	testsv := newTakecomiTestServer(t)
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	testUrl, _ := url.Parse("https://takecomic.jp/series/abcdef")
+	series, _, err := takecomiFeed(ctx, testUrl)
+	assert.Nil(t, err)
+	feed := series.Feed()
+
+	assert.Equal(t, "takecomi_seriesabcdef", series.Key)
+
+	assert.Equal(t, "テストタイトル", feed.Title)
+	assert.Equal(t, "https://takecomic.jp/series/abcdef", feed.Link.Href)
+	assert.Equal(t, "テスト原作(原作)/テスト著者(漫画)", feed.Author.Name)
+	assert.Equal(t, "テストてすとストーリー", feed.Description)
+	assert.True(t, series.Completed)
+
+	wantTime := time.Date(2024, 6, 30, 8, 0, 0, 0, jst)
+	assert.True(t, wantTime.Equal(feed.Updated),
+		"(updated)want %v,got %v", wantTime, feed.Updated)
+
+	// 第2話は読めないので除外される
+	testcases := []struct {
+		id      string
+		title   string
+		number  int
+		pricing Pricing
+		updated time.Time
+	}{
+		{
+			id:      "ep0001",
+			title:   "第1話",
+			number:  1,
+			pricing: PricingFree,
+			updated: time.Date(2024, 1, 1, 0, 0, 0, 0, jst),
+		},
+		{
+			id:      "ep0003",
+			title:   "第3話",
+			number:  3,
+			pricing: PricingFree,
+			updated: time.Date(2024, 6, 30, 8, 0, 0, 0, jst),
+		},
+	}
+
+	assert.Equal(t, len(testcases), len(series.Episodes))
+	for index, tt := range testcases {
+		t.Run(tt.title, func(t *testing.T) {
+			assert.Equal(t, tt.id, series.Episodes[index].ID)
+			assert.Equal(t, "https://takecomic.jp/episodes/"+tt.id, feed.Items[index].Link.Href)
+			assert.Equal(t, tt.title, feed.Items[index].Title)
+			assert.Equal(t, tt.number, series.Episodes[index].Number)
+			assert.Equal(t, tt.pricing, series.Episodes[index].Pricing)
+
+			assert.True(t, tt.updated.Equal(feed.Items[index].Updated),
+				"(updated)want %v,got %v", tt.updated, feed.Items[index].Updated)
+		})
+	}
+}
+
+func TestTakecomiPaid(t *testing.T) {
+	testsv := newTakecomiTestServer(t)
+	defer testsv.Close()
+
+	r := NewRegistry()
+	assert.Nil(t, r.Register(NewPrefixLoader("takecomi", "https://takecomic.jp/series/", takecomiFeed, SiteOptionFreeOnly, SiteOptionPaid)))
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	series, _, err := r.GetSeriesWithOptions(ctx, "https://takecomic.jp/series/abcdef", WithPaid())
+	assert.Nil(t, err)
+	assert.Equal(t, "takecomi_seriesabcdef_paid", series.Key)
+	assert.Equal(t, "https://takecomic.jp/series/abcdef", series.Link)
+	// 第2話は読めないので有料
+	assert.Equal(t, 3, len(series.Episodes))
+	assert.Equal(t, "ep0002", series.Episodes[1].ID)
+	assert.Equal(t, PricingPaid, series.Episodes[1].Pricing)
+
+	// WithFreeOnly takes precedence
+	series, _, err = r.GetSeriesWithOptions(ctx, "https://takecomic.jp/series/abcdef", WithPaid(), WithFreeOnly())
+	assert.Nil(t, err)
+	assert.Equal(t, "takecomi_seriesabcdef", series.Key)
+	assert.Equal(t, 2, len(series.Episodes))
+	for _, ep := range series.Episodes {
+		assert.Equal(t, PricingFree, ep.Pricing)
+	}
+}
+
+func TestTakecomiErr(t *testing.T) {
+	testsv := newTakecomiTestServer(t)
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	for _, target := range []string{
+		"https://takecomic.jp/series/",
+		"https://takecomic.jp/series/unknown",
+	} {
+		t.Run(target, func(t *testing.T) {
+			testUrl, _ := url.Parse(target)
+			series, _, err := takecomiFeed(ctx, testUrl)
+			assert.Error(t, err)
+			assert.Nil(t, series)
+		})
+	}
+}
+
+func TestDequoteTakecomiDetails(t *testing.T) {
+	got, err := dequoteTalecomiDetails(`[{"type":"paragraph","children":[{"text":"テスト"},{"text":"てすと"}]},{"type":"paragraph","children":[{"text":"ストーリー"}]}]`)
+	assert.Nil(t, err)
+	assert.Equal(t, "テストてすとストーリー", got)
+
+	_, err = dequoteTalecomiDetails("hoge")
+	assert.Error(t, err)
+}
+
+func TestTakecomiNotFound(t *testing.T) {
+	testsv := newTakecomiTestServer(t)
+	defer testsv.Close()
+
+	ctx := SetClient(context.Background(), newRewriteClient(t, testsv))
+
+	testUrl, _ := url.Parse("https://takecomic.jp/series/unknown")
+	_, _, err := takecomiFeed(ctx, testUrl)
+	var status *HTTPStatusError
+	assert.True(t, errors.As(err, &status))
+	assert.Equal(t, http.StatusNotFound, status.StatusCode)
+	assert.Equal(t, "404 page not found", status.Body)
+}
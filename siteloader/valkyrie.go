@@ -4,38 +4,37 @@ import (
 	"context"
 	"fmt"
 	"net/url"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/gorilla/feeds"
 )
 
-func valkyrieFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, HttpMetadata, error) {
+func valkyrieFeed(ctx context.Context, target *url.URL) (*Series, HttpMetadata, error) {
 	doc, metadata, err := fetchDocument(ctx, target)
 	if err != nil {
-		return "", nil, metadata, fmt.Errorf("valkyrie:FetchErr:%w", err)
+		return nil, metadata, fmt.Errorf("valkyrie:FetchErr:%w", err)
 	}
 
 	title := doc.Find("title").Text()
 	if title == "" {
-		return "", nil, metadata, fmt.Errorf("valkyrie:title not found")
+		return nil, metadata, &LayoutError{Site: "valkyrie", Selector: "title"}
 	}
 
 	author := doc.Find("#writer > p").Text()
 	if author == "" {
-		return "", nil, metadata, fmt.Errorf("valkyrie:author not found")
+		return nil, metadata, &LayoutError{Site: "valkyrie", Selector: "#writer > p"}
 	}
 	author = trimDescription(author)
 
 	desc := trimDescription(doc.Find("#bg > main > div > div.t_box > p").Text())
 	desc = trimDescription(desc)
 
-	feed := &feeds.Feed{
+	series := &Series{
+		Site:        "valkyrie",
+		Key:         "valkyrie_" + escapePath(target.Path),
 		Title:       title,
-		Link:        &feeds.Link{Href: target.String()},
+		Link:        target.String(),
 		Description: desc,
-		Author:      &feeds.Author{Name: author},
-		Created:     time.Now(),
+		Author:      author,
 	}
 
 	title = doc.Find("#new_story > div > p.title").Text()
@@ -43,11 +42,11 @@ func valkyrieFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, Ht
 	img, _ := doc.Find("#new_story > figure > img").Attr("src")
 	img, _ = resolveRelativeURI(target, img)
 
-	feed.Items = append(feed.Items, &feeds.Item{
+	series.Episodes = append(series.Episodes, &Episode{
+		ID:        generateHashedHex(href),
 		Title:     title,
-		Link:      &feeds.Link{Href: href},
-		Id:        generateHashedHex(href),
-		Enclosure: &feeds.Enclosure{Url: img},
+		Link:      href,
+		Thumbnail: img,
 	})
 
 	doc.Find("#back_number > div > div").Each(func(i int, s *goquery.Selection) {
@@ -56,17 +55,17 @@ func valkyrieFeed(ctx context.Context, target *url.URL) (string, *feeds.Feed, Ht
 		img, _ := s.Find("figure > img").Attr("src")
 		img, _ = resolveRelativeURI(target, img)
 
-		feed.Items = append(feed.Items, &feeds.Item{
+		series.Episodes = append(series.Episodes, &Episode{
+			ID:        generateHashedHex(href),
 			Title:     title,
-			Link:      &feeds.Link{Href: href},
-			Enclosure: &feeds.Enclosure{Url: img},
-			Id:        generateHashedHex(href),
+			Link:      href,
+			Thumbnail: img,
 		})
 	})
 
-	if len(feed.Items) == 0 {
-		return "", nil, metadata, fmt.Errorf("valkyrie:no episode entry")
+	if len(series.Episodes) == 0 {
+		return nil, metadata, &NoEpisodesError{Site: "valkyrie", URL: target.String()}
 	}
 
-	return "valkyrie_" + escapePath(target.Path), feed, metadata, nil
+	return series, metadata, nil
 }
@@ -27,10 +27,11 @@ func TestValkyrie(t *testing.T) {
 
 	testUrl, _ := url.Parse(testsv.URL + "/path_t/est")
 
-	fname, feed, _, err := valkyrieFeed(context.Background(), testUrl)
+	series, _, err := valkyrieFeed(context.Background(), testUrl)
 	assert.Nil(t, err)
+	feed := series.Feed()
 
-	assert.Equal(t, "valkyrie_path_test", fname)
+	assert.Equal(t, "valkyrie_path_test", series.Key)
 
 	assert.Equal(t, "テストタイトル", feed.Title)
 	assert.Equal(t, testUrl.String(), feed.Link.Href)
@@ -62,7 +63,7 @@ func TestValkyrie(t *testing.T) {
 	for index, tt := range testcases {
 		t.Run(tt.title, func(t *testing.T) {
 			absimg, _ := resolveRelativeURI(testUrl, tt.thumb)
-			assert.Equal(t, generateHashedHex(tt.path), feed.Items[index].Id)
+			assert.Equal(t, generateHashedHex(tt.path), series.Episodes[index].ID)
 			assert.Equal(t, tt.path, feed.Items[index].Link.Href)
 			assert.Equal(t, absimg, feed.Items[index].Enclosure.Url)
 			assert.Equal(t, tt.title, feed.Items[index].Title)